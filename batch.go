@@ -0,0 +1,203 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// BatchOptions controls how the requests of a batch are processed.
+// The zero value processes all requests concurrently once the whole batch has been parsed,
+// and returns responses in completion order.
+type BatchOptions struct {
+	// MaxSize is the maximum number of requests in a batch. Larger batches are rejected
+	// with a single CodeInvalidRequest error. Zero means no limit.
+	MaxSize int
+
+	// MaxConcurrency is the maximum number of requests of a batch processed at the same time.
+	// Zero means no limit.
+	MaxConcurrency int
+
+	// PreserveOrder returns the responses in the order of the requests instead of completion order.
+	PreserveOrder bool
+
+	// Sequential processes the requests one after the other, in order. It implies PreserveOrder.
+	Sequential bool
+
+	// Stream dispatches the requests of batches sent over HTTP as soon as they are parsed,
+	// before the end of the body is read. It is ignored when MaxSize is set.
+	//
+	// This departs from JSON-RPC 2.0: if the rest of the body is invalid or too large, the requests
+	// already dispatched still complete, and the response is an array of their responses followed
+	// by the error, instead of a single error.
+	Stream bool
+}
+
+// SetBatchOptions sets how batches are processed by all transports.
+func (s *Server) SetBatchOptions(opts BatchOptions) {
+	s.batchOptions = opts
+}
+
+// checkBatchSize returns an error response if a batch of n requests exceeds BatchOptions.MaxSize.
+func (s *Server) checkBatchSize(n int) (RPCResponse, bool) {
+	if maxSize := s.batchOptions.MaxSize; maxSize > 0 && n > maxSize {
+		resp := newErrorResponse(nil, CodeInvalidRequest, "Batch too large")
+		resp.Error.Data = map[string]int{"maxSize": maxSize}
+		return resp, false
+	}
+	return RPCResponse{}, true
+}
+
+var (
+	errEmptyBatch    = errors.New("empty batch")
+	errBatchTooLarge = errors.New("batch too large")
+)
+
+// batchErrorResponse returns the response to a batch that failed with err, and its HTTP status.
+func (s *Server) batchErrorResponse(err error) (RPCResponse, int) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, errEmptyBatch):
+		return newErrorResponse(nil, CodeInvalidRequest, "Empty batch"), http.StatusBadRequest
+	case errors.Is(err, errBatchTooLarge):
+		resp, _ := s.checkBatchSize(s.batchOptions.MaxSize + 1)
+		return resp, http.StatusBadRequest
+	case errors.As(err, &maxBytesErr):
+		return bodyTooLargeResponse(maxBytesErr.Limit), http.StatusRequestEntityTooLarge
+	default:
+		return newErrorResponse(nil, CodeParseError, "Failed to parse JSON batch"), http.StatusBadRequest
+	}
+}
+
+// processBatch processes the elements of a batch and returns the responses of the requests
+// that are not notifications.
+func (s *Server) processBatch(ctx context.Context, elements []json.RawMessage) []RPCResponse {
+	i := 0
+	responses, _ := s.processBatchStream(ctx, func() (json.RawMessage, bool, error) {
+		if i == len(elements) {
+			return nil, false, nil
+		}
+		i++
+		return elements[i-1], true, nil
+	})
+	return responses
+}
+
+// processBatchStream processes the elements returned by next until next returns false.
+// Each element is validated on its own, and invalid ones get a CodeInvalidRequest response
+// while the others are processed. Requests are dispatched to at most BatchOptions.MaxConcurrency
+// goroutines, or processed in order by the calling goroutine if BatchOptions.Sequential is set.
+//
+// Elements are buffered until the end of the batch, or until one more than BatchOptions.MaxSize
+// has been read, so nothing is dispatched if next fails or the batch is too large.
+// With BatchOptions.Stream and no MaxSize, elements are dispatched as soon as they are available;
+// if next fails later, the requests already dispatched complete and their responses are returned
+// with the error.
+func (s *Server) processBatchStream(ctx context.Context, next func() (json.RawMessage, bool, error)) ([]RPCResponse, error) {
+	opts := s.batchOptions
+
+	var sem chan struct{}
+	if opts.MaxConcurrency > 0 {
+		sem = make(chan struct{}, opts.MaxConcurrency)
+	}
+
+	// Responses are stored by request index when the order is preserved, and appended otherwise.
+	ordered := opts.PreserveOrder || opts.Sequential
+	var indexed []*RPCResponse
+	var responses []RPCResponse
+	var responsesMu sync.Mutex
+	var wg sync.WaitGroup
+
+	process := func(i int, element json.RawMessage) {
+		req, resp := parseRequest(element)
+		if resp == nil {
+			processed := s.processRequest(ctx, req)
+			// 4.1 Notification: "The Server MUST NOT reply to a Notification"
+			if req.ID == nil {
+				return
+			}
+			resp = &processed
+		}
+
+		responsesMu.Lock()
+		if ordered {
+			indexed[i] = resp
+		} else {
+			responses = append(responses, *resp)
+		}
+		responsesMu.Unlock()
+	}
+
+	dispatch := func(i int, element json.RawMessage) {
+		if ordered {
+			responsesMu.Lock()
+			indexed = append(indexed, nil)
+			responsesMu.Unlock()
+		}
+
+		if opts.Sequential {
+			process(i, element)
+			return
+		}
+		if sem != nil {
+			sem <- struct{}{}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sem != nil {
+				defer func() { <-sem }()
+			}
+			process(i, element)
+		}()
+	}
+
+	stream := opts.Stream && opts.MaxSize <= 0
+	var buffered []json.RawMessage
+	count := 0
+	var err error
+	for {
+		element, ok, nextErr := next()
+		if nextErr != nil {
+			err = nextErr
+			break
+		}
+		if !ok {
+			break
+		}
+
+		count++
+		if stream {
+			dispatch(count-1, element)
+			continue
+		}
+		if opts.MaxSize > 0 && count > opts.MaxSize {
+			err = errBatchTooLarge
+			break
+		}
+		buffered = append(buffered, element)
+	}
+	if err == nil && count == 0 {
+		err = errEmptyBatch
+	}
+	if !stream {
+		if err != nil {
+			return nil, err
+		}
+		for i, element := range buffered {
+			dispatch(i, element)
+		}
+	}
+	wg.Wait()
+
+	if ordered {
+		for _, resp := range indexed {
+			if resp != nil {
+				responses = append(responses, *resp)
+			}
+		}
+	}
+	return responses, err
+}
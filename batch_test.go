@@ -0,0 +1,253 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPBatchStreaming(t *testing.T) {
+	const (
+		call1 = `{"jsonrpc":"2.0","method":"count","id":1}`
+		call2 = `{"jsonrpc":"2.0","method":"count","id":2}`
+		call3 = `{"jsonrpc":"2.0","method":"count","id":3}`
+	)
+
+	tests := []struct {
+		name         string
+		opts         BatchOptions
+		maxBodyBytes int64
+		body         string
+		wantStatus   int
+		wantCalls    int32
+		wantIDs      []string // ids of the responses, "null" for the batch error
+		wantCode     int      // code of the batch error, if any
+	}{
+		{
+			name:       "within max size",
+			opts:       BatchOptions{MaxSize: 2, PreserveOrder: true},
+			body:       "[" + call1 + "," + call2 + "]",
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantIDs:    []string{"1", "2"},
+		},
+		{
+			name:       "too large runs nothing",
+			opts:       BatchOptions{MaxSize: 2},
+			body:       "[" + call1 + "," + call2 + "," + call3 + "]",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeInvalidRequest,
+		},
+		{
+			name:       "parse error with max size runs nothing",
+			opts:       BatchOptions{MaxSize: 10},
+			body:       "[" + call1 + "," + call2 + ",{",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:         "body too large with max size runs nothing",
+			opts:         BatchOptions{MaxSize: 10},
+			maxBodyBytes: int64(len(call1) + 10),
+			body:         "[" + call1 + "," + call2 + "]",
+			wantStatus:   http.StatusRequestEntityTooLarge,
+			wantIDs:      []string{"null"},
+			wantCode:     CodeInvalidRequest,
+		},
+		{
+			name:       "parse error runs nothing",
+			opts:       BatchOptions{Sequential: true},
+			body:       "[" + call1 + "," + call2 + ",{",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "trailing data runs nothing",
+			body:       "[" + call1 + "] xx",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "truncated batch runs nothing",
+			body:       "[" + call1 + "," + call2,
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:         "body too large runs nothing",
+			maxBodyBytes: int64(len(call1) + 10),
+			body:         "[" + call1 + "," + call2 + "]",
+			wantStatus:   http.StatusRequestEntityTooLarge,
+			wantIDs:      []string{"null"},
+			wantCode:     CodeInvalidRequest,
+		},
+		{
+			name:       "stream with max size runs nothing",
+			opts:       BatchOptions{Stream: true, MaxSize: 10},
+			body:       "[" + call1 + "," + call2 + ",{",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "parse error while streaming returns partial responses",
+			opts:       BatchOptions{Stream: true, Sequential: true},
+			body:       "[" + call1 + "," + call2 + ",{",
+			wantStatus: http.StatusBadRequest,
+			wantCalls:  2,
+			wantIDs:    []string{"1", "2", "null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "trailing data",
+			opts:       BatchOptions{MaxSize: 10},
+			body:       "[" + call1 + "] garbage",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "trailing value",
+			opts:       BatchOptions{MaxSize: 10},
+			body:       "[" + call1 + "] {}",
+			wantStatus: http.StatusBadRequest,
+			wantIDs:    []string{"null"},
+			wantCode:   CodeParseError,
+		},
+		{
+			name:       "trailing whitespace",
+			body:       "[" + call1 + "]\n\t ",
+			wantStatus: http.StatusOK,
+			wantCalls:  1,
+			wantIDs:    []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := NewServer()
+			server.SetBatchOptions(tt.opts)
+			server.SetMaxBodyBytes(tt.maxBodyBytes)
+			RegisterMethod(server, "count", func(ctx context.Context, p EmptyParams) (int32, error) {
+				return calls.Add(1), nil
+			})
+
+			rec := httptest.NewRecorder()
+			HTTPHandler(server).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+
+			var responses []struct {
+				ID    json.RawMessage `json:"id"`
+				Error *RPCError       `json:"error"`
+			}
+			body := strings.TrimSpace(rec.Body.String())
+			if !strings.HasPrefix(body, "[") {
+				body = "[" + body + "]"
+			}
+			if err := json.Unmarshal([]byte(body), &responses); err != nil {
+				t.Fatalf("invalid response %s: %v", rec.Body, err)
+			}
+
+			var ids []string
+			for _, resp := range responses {
+				ids = append(ids, string(resp.ID))
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("response ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.wantCode != 0 {
+				last := responses[len(responses)-1]
+				if last.Error == nil || last.Error.Code != tt.wantCode {
+					t.Errorf("batch error = %+v, want code %d", last.Error, tt.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestHTTPBatchConcurrency(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           BatchOptions
+		wantMax        int32
+		wantInOrder    bool
+		wantConcurrent bool
+	}{
+		{name: "unlimited", opts: BatchOptions{PreserveOrder: true}, wantInOrder: true, wantConcurrent: true},
+		{name: "max concurrency", opts: BatchOptions{MaxConcurrency: 2}, wantMax: 2, wantConcurrent: true},
+		{name: "sequential", opts: BatchOptions{Sequential: true}, wantMax: 1, wantInOrder: true},
+	}
+
+	const size = 8
+	var body strings.Builder
+	body.WriteString("[")
+	for i := range size {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		body.WriteString(`{"jsonrpc":"2.0","method":"work","params":` + string(rune('0'+i)) + `,"id":` + string(rune('0'+i)) + `}`)
+	}
+	body.WriteString("]")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, maxRunning atomic.Int32
+			var mu sync.Mutex
+			server := NewServer()
+			server.SetBatchOptions(tt.opts)
+			RegisterMethod(server, "work", func(ctx context.Context, n int) (int, error) {
+				current := running.Add(1)
+				defer running.Add(-1)
+				mu.Lock()
+				if current > maxRunning.Load() {
+					maxRunning.Store(current)
+				}
+				mu.Unlock()
+				time.Sleep(10 * time.Millisecond)
+				return n, nil
+			})
+
+			rec := postHTTP(HTTPHandler(server), body.String(), nil)
+			var responses []RPCResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &responses); err != nil {
+				t.Fatalf("invalid response %s: %v", rec.Body, err)
+			}
+			if len(responses) != size {
+				t.Fatalf("got %d responses, want %d", len(responses), size)
+			}
+
+			got := maxRunning.Load()
+			if tt.wantMax > 0 && got > tt.wantMax {
+				t.Errorf("max concurrent requests = %d, want at most %d", got, tt.wantMax)
+			}
+			if tt.wantConcurrent && got < 2 {
+				t.Errorf("max concurrent requests = %d, want concurrent processing", got)
+			}
+			if tt.wantInOrder {
+				for i, resp := range responses {
+					if want := string(rune('0' + i)); string(resp.ID) != want {
+						t.Errorf("response %d has id %s, want %s", i, resp.ID, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// CancelRequestMethod is the notification a client sends on a persistent connection to cancel
+// one of its in-flight requests. Its params are {"id": <id of the request>}.
+//
+// The context of the matching handler is cancelled with ErrRequestCancelled as cause,
+// and the client receives a CodeRequestCancelled error instead of the result.
+const CancelRequestMethod = "$/cancelRequest"
+
+// ErrRequestCancelled is the cause of the context of a request cancelled by the client,
+// as returned by context.Cause.
+var ErrRequestCancelled = errors.New("autorpc: request cancelled by the client")
+
+// CancelRequestParams are the params of CancelRequestMethod.
+type CancelRequestParams struct {
+	ID json.RawMessage `json:"id"`
+}
+
+// inflightRequests is the registry of the requests being processed on a connection, by id.
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[string]*inflightRequest
+}
+
+type inflightRequest struct {
+	cancel context.CancelCauseFunc
+}
+
+// track registers the request with the given id until the returned function is called.
+func (r *inflightRequests) track(id json.RawMessage, cancel context.CancelCauseFunc) func() {
+	key := string(id)
+	req := &inflightRequest{cancel: cancel}
+
+	r.mu.Lock()
+	if r.requests == nil {
+		r.requests = make(map[string]*inflightRequest)
+	}
+	r.requests[key] = req
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// A later request may reuse the id
+		if r.requests[key] == req {
+			delete(r.requests, key)
+		}
+	}
+}
+
+// cancel cancels the request with the given id. It reports whether the request was in flight.
+func (r *inflightRequests) cancel(id json.RawMessage) bool {
+	r.mu.Lock()
+	req, ok := r.requests[string(id)]
+	r.mu.Unlock()
+
+	if ok {
+		req.cancel(ErrRequestCancelled)
+	}
+	return ok
+}
+
+type inflightRequestsKey struct{}
+
+func withInflightRequests(ctx context.Context, r *inflightRequests) context.Context {
+	return context.WithValue(ctx, inflightRequestsKey{}, r)
+}
+
+func inflightRequestsFromContext(ctx context.Context) *inflightRequests {
+	r, _ := ctx.Value(inflightRequestsKey{}).(*inflightRequests)
+	return r
+}
+
+// trackRequest makes req cancellable with CancelRequestMethod if it was received on a connection
+// that supports it, and reports whether it did. The returned function must be called when
+// the request completes.
+func trackRequest(ctx context.Context, req RPCRequest) (context.Context, func(), bool) {
+	inflight := inflightRequestsFromContext(ctx)
+	if inflight == nil || req.ID == nil {
+		return ctx, func() {}, false
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	untrack := inflight.track(req.ID, cancel)
+	return ctx, func() {
+		untrack()
+		cancel(nil)
+	}, true
+}
+
+// processCancelRequest handles a CancelRequestMethod message. If it has an id,
+// the result reports whether the request was in flight.
+func processCancelRequest(ctx context.Context, req RPCRequest) RPCResponse {
+	inflight := inflightRequestsFromContext(ctx)
+	if inflight == nil {
+		return newErrorResponse(req.ID, CodeInvalidRequest, "Request cancellation requires a persistent connection")
+	}
+
+	var params CancelRequestParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.ID == nil {
+		return newErrorResponse(req.ID, CodeInvalidParams, "Invalid params: expected {\"id\": <request id>}")
+	}
+
+	return RPCResponse{
+		JSONRPC: "2.0",
+		Result:  inflight.cancel(params.ID),
+		ID:      req.ID,
+	}
+}
@@ -0,0 +1,126 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCancelRequest(t *testing.T) {
+	server := newTestServer()
+	started := make(chan struct{}, 1)
+	RegisterMethod(server, "block", func(ctx context.Context, p EmptyParams) (bool, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		if !errors.Is(context.Cause(ctx), ErrRequestCancelled) {
+			t.Errorf("context cause = %v, want ErrRequestCancelled", context.Cause(ctx))
+		}
+		return false, ctx.Err()
+	})
+	client := newStreamClient(t, context.Background(), server, FramingNewline)
+
+	tests := []struct {
+		name   string
+		cancel string
+		want   []string
+	}{
+		{
+			name:   "notification",
+			cancel: `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"a"}}`,
+			want:   []string{`{"jsonrpc":"2.0","error":{"code":-32800},"id":"a"}`},
+		},
+		{
+			name:   "request",
+			cancel: `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"a"},"id":"c"}`,
+			want: []string{
+				`{"jsonrpc":"2.0","result":true,"id":"c"}`,
+				`{"jsonrpc":"2.0","error":{"code":-32800},"id":"a"}`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.t = t
+			client.send(`{"jsonrpc":"2.0","method":"block","id":"a"}`)
+			<-started
+			client.send(tt.cancel)
+
+			got := map[string][]byte{}
+			for range tt.want {
+				var resp RPCResponse
+				data := client.recv()
+				json.Unmarshal(data, &resp)
+				got[string(resp.ID)] = data
+			}
+			for _, want := range tt.want {
+				var resp RPCResponse
+				json.Unmarshal([]byte(want), &resp)
+				assertResponses(t, got[string(resp.ID)], want)
+			}
+		})
+	}
+
+	t.Run("unknown id", func(t *testing.T) {
+		client.t = t
+		client.send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":"missing"},"id":1}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":false,"id":1}`)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		client.t = t
+		client.send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{},"id":1}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","error":{"code":-32602},"id":1}`)
+	})
+}
+
+func TestCancelRequestWebSocket(t *testing.T) {
+	server := newTestServer()
+	started := make(chan struct{}, 1)
+	RegisterMethod(server, "block", func(ctx context.Context, p EmptyParams) (bool, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+	httpServer := httptest.NewServer(WebSocketHandler(server))
+	defer httpServer.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(httpServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"block","id":7}`))
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request not started")
+	}
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":7}}`))
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertResponses(t, data, `{"jsonrpc":"2.0","error":{"code":-32800},"id":7}`)
+}
+
+func TestCancelRequestHTTP(t *testing.T) {
+	handler := HTTPHandler(newTestServer())
+
+	rec := postHTTP(handler, `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1},"id":2}`, nil)
+	assertResponses(t, rec.Body.Bytes(), `{"jsonrpc":"2.0","error":{"code":-32600},"id":2}`)
+
+	rec = postHTTP(handler, `{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":1}}`, nil)
+	if rec.Code != http.StatusNoContent || rec.Body.Len() != 0 {
+		t.Errorf("notification: status %d body %q, want 204 and no body", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,178 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrBatchNotSent is returned by Result.Get when the batch has not been sent yet.
+	ErrBatchNotSent = errors.New("client: batch not sent")
+	// ErrNoResponse is returned by Result.Get when the server did not reply to the request.
+	ErrNoResponse = errors.New("client: no response for request")
+)
+
+// Batch groups several calls and notifications into a single JSON-RPC batch request.
+//
+// Example:
+//
+//	b := c.NewBatch()
+//	sum := client.Add[[]float32, float32](b, "math.sum", []float32{1, 2, 3})
+//	fact := client.Add[int, int](b, "math.factorial", 5)
+//	if err := b.Send(ctx); err != nil {
+//	    return err
+//	}
+//	s, err := sum.Get()
+type Batch struct {
+	client  *Client
+	entries []batchEntry
+}
+
+type batchEntry struct {
+	message interface{}
+	id      json.RawMessage // nil for notifications
+	deliver func(resp response, err error)
+}
+
+// Result holds the outcome of a call added to a batch. It is available once the batch is sent.
+type Result[R any] struct {
+	value R
+	err   error
+	done  bool
+}
+
+// Get returns the result of the call, or the error returned by the server for this call.
+func (r *Result[R]) Get() (R, error) {
+	if !r.done {
+		return r.value, ErrBatchNotSent
+	}
+	return r.value, r.err
+}
+
+// NewBatch creates an empty batch sent through c.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{client: c}
+}
+
+// Add adds a call to the batch and returns its result, which is filled when the batch is sent.
+func Add[P, R any](b *Batch, method string, params P) *Result[R] {
+	result := &Result[R]{}
+
+	req, err := b.client.newRequest(method, params)
+	if err != nil {
+		result.err = err
+		result.done = true
+		return result
+	}
+
+	b.entries = append(b.entries, batchEntry{
+		message: req,
+		id:      req.ID,
+		deliver: func(resp response, err error) {
+			result.done = true
+			if err != nil {
+				result.err = err
+				return
+			}
+			result.err = decodeResult(resp, &result.value)
+		},
+	})
+	return result
+}
+
+// AddNotification adds a notification to the batch. The server does not reply to notifications.
+func AddNotification[P any](b *Batch, method string, params P) error {
+	notification, err := newNotification(method, params)
+	if err != nil {
+		return err
+	}
+	b.entries = append(b.entries, batchEntry{message: notification})
+	return nil
+}
+
+// Len returns the number of calls and notifications in the batch.
+func (b *Batch) Len() int {
+	return len(b.entries)
+}
+
+// Send sends the batch and fills the results of its calls.
+// The returned error only reports failures affecting the whole batch;
+// errors of individual calls are returned by their Result.
+func (b *Batch) Send(ctx context.Context) error {
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	messages := make([]interface{}, 0, len(b.entries))
+	for _, entry := range b.entries {
+		messages = append(messages, entry.message)
+	}
+
+	body, err := b.client.send(ctx, messages)
+	if err != nil {
+		b.fail(err)
+		return err
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		b.deliver(nil)
+		return nil
+	}
+
+	// The server replies with a single error when the batch itself is invalid.
+	if body[0] == '{' {
+		var resp response
+		if err := json.Unmarshal(body, &resp); err != nil {
+			err = fmt.Errorf("client: invalid response: %w", err)
+			b.fail(err)
+			return err
+		}
+		err := errors.New("client: unexpected single response to batch")
+		if resp.Error != nil {
+			err = decodeResult(resp, nil)
+		}
+		b.fail(err)
+		return err
+	}
+
+	var responses []response
+	if err := json.Unmarshal(body, &responses); err != nil {
+		err = fmt.Errorf("client: invalid response: %w", err)
+		b.fail(err)
+		return err
+	}
+	b.deliver(responses)
+	return nil
+}
+
+// deliver matches responses to the calls of the batch by id.
+func (b *Batch) deliver(responses []response) {
+	byID := make(map[string]response, len(responses))
+	for _, resp := range responses {
+		byID[string(bytes.TrimSpace(resp.ID))] = resp
+	}
+
+	for _, entry := range b.entries {
+		if entry.id == nil {
+			continue
+		}
+		resp, ok := byID[string(entry.id)]
+		if !ok {
+			entry.deliver(response{}, ErrNoResponse)
+			continue
+		}
+		entry.deliver(resp, nil)
+	}
+}
+
+func (b *Batch) fail(err error) {
+	for _, entry := range b.entries {
+		if entry.deliver != nil {
+			entry.deliver(response{}, err)
+		}
+	}
+}
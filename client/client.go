@@ -0,0 +1,244 @@
+// Package client implements a typed JSON-RPC 2.0 client for autorpc servers.
+//
+// Example:
+//
+//	c := client.New("http://localhost:8080/rpc")
+//	sum, err := client.Call[AddParams, float32](ctx, c, "math.add", AddParams{A: 1, B: 2})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Lexographics/autorpc"
+)
+
+type Client struct {
+	url        string
+	httpClient *http.Client
+	header     http.Header
+	nextID     atomic.Uint64
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client used to send requests. Defaults to http.DefaultClient.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithHeader adds a header sent with every request.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+// New creates a client sending requests to the autorpc HTTP endpoint at url.
+func New(url string, opts ...Option) *Client {
+	c := &Client{
+		url:        url,
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// response mirrors autorpc.RPCResponse, but keeps the result and error data raw
+// so they can be decoded into the caller's types.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+type responseError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error is an error returned by the server. It implements autorpc.RPCErrorProvider,
+// so it keeps its code, message and data when returned from an autorpc handler.
+type Error struct {
+	code    int
+	message string
+	data    json.RawMessage
+}
+
+var _ autorpc.RPCErrorProvider = (*Error)(nil)
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.code, e.message)
+}
+
+func (e *Error) Code() int {
+	return e.code
+}
+
+func (e *Error) Message() string {
+	return e.message
+}
+
+// Data returns the raw JSON data of the error, or nil if the server did not send any.
+func (e *Error) Data() interface{} {
+	if len(e.data) == 0 {
+		return nil
+	}
+	return e.data
+}
+
+// DecodeData decodes the data of the error into v.
+func (e *Error) DecodeData(v interface{}) error {
+	if len(e.data) == 0 {
+		return errors.New("client: error has no data")
+	}
+	return json.Unmarshal(e.data, v)
+}
+
+// Call calls the method with the given params and decodes the result into R.
+// Errors returned by the server are of type *Error.
+func Call[P, R any](ctx context.Context, c *Client, method string, params P) (R, error) {
+	var result R
+
+	req, err := c.newRequest(method, params)
+	if err != nil {
+		return result, err
+	}
+
+	body, err := c.send(ctx, req)
+	if err != nil {
+		return result, err
+	}
+	if len(body) == 0 {
+		return result, errors.New("client: empty response")
+	}
+
+	var resp response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return result, fmt.Errorf("client: invalid response: %w", err)
+	}
+	// The id is null when the server could not read the request, which only happens with an error.
+	id := bytes.TrimSpace(resp.ID)
+	if !bytes.Equal(id, req.ID) && !(resp.Error != nil && (len(id) == 0 || string(id) == "null")) {
+		return result, fmt.Errorf("client: response id %s does not match request id %s", id, req.ID)
+	}
+	if err := decodeResult(resp, &result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// Notify sends a notification: a request without an id, that the server does not reply to.
+func Notify[P any](ctx context.Context, c *Client, method string, params P) error {
+	notification, err := newNotification(method, params)
+	if err != nil {
+		return err
+	}
+	_, err = c.send(ctx, notification)
+	return err
+}
+
+func (c *Client) newRequest(method string, params interface{}) (autorpc.RPCRequest, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return autorpc.RPCRequest{}, fmt.Errorf("client: failed to marshal params: %w", err)
+	}
+
+	id := strconv.FormatUint(c.nextID.Add(1), 10)
+	return autorpc.RPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  rawParams,
+		ID:      json.RawMessage(id),
+	}, nil
+}
+
+func newNotification(method string, params interface{}) (autorpc.RPCNotification, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return autorpc.RPCNotification{}, fmt.Errorf("client: failed to marshal params: %w", err)
+	}
+
+	return autorpc.RPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  json.RawMessage(rawParams),
+	}, nil
+}
+
+// send posts v as JSON and returns the response body, which is empty
+// if the server had nothing to reply (notifications).
+func (c *Client) send(ctx context.Context, v interface{}) ([]byte, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		httpReq.Header[key] = values
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout := time.Until(deadline).Milliseconds(); timeout > 0 {
+			httpReq.Header.Set(autorpc.TimeoutHeader, strconv.FormatInt(timeout, 10))
+		}
+	}
+
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if httpResp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+
+	// autorpc replies to malformed requests with a JSON-RPC error and a 4xx status,
+	// so only fail here if the body is not JSON.
+	if httpResp.StatusCode != http.StatusOK && !json.Valid(body) {
+		return nil, fmt.Errorf("client: unexpected HTTP status %s", httpResp.Status)
+	}
+	return body, nil
+}
+
+func decodeResult(resp response, result interface{}) error {
+	if resp.Error != nil {
+		return &Error{
+			code:    resp.Error.Code,
+			message: resp.Error.Message,
+			data:    resp.Error.Data,
+		}
+	}
+	if len(resp.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Result, result); err != nil {
+		return fmt.Errorf("client: failed to decode result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lexographics/autorpc"
+)
+
+type addParams struct {
+	A int `json:"a" validate:"required"`
+	B int `json:"b"`
+}
+
+type errorData struct {
+	Field string `json:"field"`
+}
+
+// newTestServer starts an HTTP server for an autorpc server with a few methods.
+// notified receives the params of the "notify" method.
+func newTestServer(t *testing.T) (*Client, chan string) {
+	t.Helper()
+	notified := make(chan string, 1)
+
+	server := autorpc.NewServer()
+	server.SetMaxBodyBytes(1024)
+	autorpc.RegisterMethod(server, "add", func(ctx context.Context, p addParams) (int, error) {
+		return p.A + p.B, nil
+	})
+	autorpc.RegisterMethod(server, "fail", func(ctx context.Context, p autorpc.EmptyParams) (int, error) {
+		return 0, autorpc.NewError(1001, "failed", errorData{Field: "a"})
+	})
+	autorpc.RegisterMethod(server, "notify", func(ctx context.Context, s string) (bool, error) {
+		notified <- s
+		return true, nil
+	})
+
+	httpServer := httptest.NewServer(autorpc.HTTPHandler(server))
+	t.Cleanup(httpServer.Close)
+	return New(httpServer.URL), notified
+}
+
+// newRawServer starts an HTTP server replying to every request with status and body.
+func newRawServer(t *testing.T, status int, body string) *Client {
+	t.Helper()
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(httpServer.Close)
+	return New(httpServer.URL)
+}
+
+func TestCall(t *testing.T) {
+	c, _ := newTestServer(t)
+	ctx := context.Background()
+
+	sum, err := Call[addParams, int](ctx, c, "add", addParams{A: 1, B: 2})
+	if err != nil || sum != 3 {
+		t.Errorf("add = %d, %v, want 3", sum, err)
+	}
+
+	_, err = Call[autorpc.EmptyParams, int](ctx, c, "fail", autorpc.EmptyParams{})
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) {
+		t.Fatalf("fail error = %v, want *Error", err)
+	}
+	if rpcErr.Code() != 1001 || rpcErr.Message() != "failed" {
+		t.Errorf("error = %d %q, want 1001 \"failed\"", rpcErr.Code(), rpcErr.Message())
+	}
+	var data errorData
+	if err := rpcErr.DecodeData(&data); err != nil || data.Field != "a" {
+		t.Errorf("error data = %+v, %v, want field a", data, err)
+	}
+
+	_, err = Call[addParams, int](ctx, c, "add", addParams{B: 2})
+	if !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeInvalidParams {
+		t.Errorf("invalid params error = %v, want code %d", err, autorpc.CodeInvalidParams)
+	}
+
+	_, err = Call[addParams, int](ctx, c, "missing", addParams{})
+	if !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeMethodNotFound {
+		t.Errorf("missing method error = %v, want code %d", err, autorpc.CodeMethodNotFound)
+	}
+}
+
+func TestCallHTTPErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("JSON error body", func(t *testing.T) {
+		// The server rejects the body with status 413 and a JSON-RPC error.
+		c, _ := newTestServer(t)
+		_, err := Call[string, string](ctx, c, "notify", strings.Repeat("a", 2048))
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeInvalidRequest {
+			t.Errorf("error = %v, want code %d", err, autorpc.CodeInvalidRequest)
+		}
+	})
+
+	t.Run("JSON error body with null id", func(t *testing.T) {
+		c := newRawServer(t, http.StatusBadRequest, `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`)
+		_, err := Call[int, int](ctx, c, "add", 1)
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeParseError {
+			t.Errorf("error = %v, want code %d", err, autorpc.CodeParseError)
+		}
+	})
+
+	t.Run("non-JSON body", func(t *testing.T) {
+		c := newRawServer(t, http.StatusBadGateway, "bad gateway")
+		_, err := Call[int, int](ctx, c, "add", 1)
+		if err == nil || !strings.Contains(err.Error(), "502") {
+			t.Errorf("error = %v, want unexpected HTTP status", err)
+		}
+	})
+}
+
+func TestCallIDMismatch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "result", body: `{"jsonrpc":"2.0","result":1,"id":42}`},
+		{name: "error", body: `{"jsonrpc":"2.0","error":{"code":1,"message":"x"},"id":42}`},
+		{name: "null id with result", body: `{"jsonrpc":"2.0","result":1,"id":null}`},
+		{name: "string id", body: `{"jsonrpc":"2.0","result":1,"id":"1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newRawServer(t, http.StatusOK, tt.body)
+			_, err := Call[int, int](context.Background(), c, "add", 1)
+			if err == nil || !strings.Contains(err.Error(), "does not match") {
+				t.Errorf("error = %v, want id mismatch", err)
+			}
+		})
+	}
+}
+
+func TestNotify(t *testing.T) {
+	c, notified := newTestServer(t)
+
+	if err := Notify(context.Background(), c, "notify", "hello"); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	select {
+	case got := <-notified:
+		if got != "hello" {
+			t.Errorf("notified with %q, want hello", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification not received")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	c, notified := newTestServer(t)
+
+	b := c.NewBatch()
+	sum := Add[addParams, int](b, "add", addParams{A: 1, B: 2})
+	failed := Add[autorpc.EmptyParams, int](b, "fail", autorpc.EmptyParams{})
+	if err := AddNotification(b, "notify", "batched"); err != nil {
+		t.Fatal(err)
+	}
+	missing := Add[int, int](b, "missing", 1)
+	if b.Len() != 4 {
+		t.Errorf("Len = %d, want 4", b.Len())
+	}
+
+	if _, err := sum.Get(); err != ErrBatchNotSent {
+		t.Errorf("Get before Send = %v, want ErrBatchNotSent", err)
+	}
+
+	if err := b.Send(context.Background()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got, err := sum.Get(); err != nil || got != 3 {
+		t.Errorf("add = %d, %v, want 3", got, err)
+	}
+	var rpcErr *Error
+	if _, err := failed.Get(); !errors.As(err, &rpcErr) || rpcErr.Code() != 1001 {
+		t.Errorf("fail = %v, want code 1001", err)
+	}
+	if _, err := missing.Get(); !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeMethodNotFound {
+		t.Errorf("missing = %v, want code %d", err, autorpc.CodeMethodNotFound)
+	}
+	select {
+	case got := <-notified:
+		if got != "batched" {
+			t.Errorf("notified with %q, want batched", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("notification not received")
+	}
+}
+
+func TestBatchErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("only notifications", func(t *testing.T) {
+		c, notified := newTestServer(t)
+		b := c.NewBatch()
+		AddNotification(b, "notify", "a")
+		if err := b.Send(ctx); err != nil {
+			t.Errorf("Send = %v, want nil", err)
+		}
+		<-notified
+	})
+
+	t.Run("missing response", func(t *testing.T) {
+		c := newRawServer(t, http.StatusOK, `[{"jsonrpc":"2.0","result":1,"id":1}]`)
+		b := c.NewBatch()
+		first := Add[int, int](b, "a", 1)
+		second := Add[int, int](b, "b", 2)
+		if err := b.Send(ctx); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+		if got, err := first.Get(); err != nil || got != 1 {
+			t.Errorf("first = %d, %v, want 1", got, err)
+		}
+		if _, err := second.Get(); err != ErrNoResponse {
+			t.Errorf("second = %v, want ErrNoResponse", err)
+		}
+	})
+
+	t.Run("batch rejected", func(t *testing.T) {
+		c := newRawServer(t, http.StatusBadRequest, `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`)
+		b := c.NewBatch()
+		result := Add[int, int](b, "a", 1)
+		err := b.Send(ctx)
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code() != autorpc.CodeInvalidRequest {
+			t.Fatalf("Send = %v, want code %d", err, autorpc.CodeInvalidRequest)
+		}
+		if _, resultErr := result.Get(); resultErr != err {
+			t.Errorf("result error = %v, want %v", resultErr, err)
+		}
+	})
+}
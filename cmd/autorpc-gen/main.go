@@ -0,0 +1,87 @@
+// Command autorpc-gen generates TypeScript definitions and a typed client
+// from the spec served by autorpc.SpecJSONHandler.
+//
+// Usage:
+//
+//	autorpc-gen -spec http://localhost:8080/spec.json -out src/api.ts
+//	autorpc-gen -spec spec.json > api.ts
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Lexographics/autorpc"
+)
+
+func main() {
+	specPath := flag.String("spec", "", "URL or file path of the spec JSON (\"-\" for stdin)")
+	outPath := flag.String("out", "", "output file (defaults to stdout)")
+	flag.Parse()
+
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*specPath, *outPath); err != nil {
+		fmt.Fprintln(os.Stderr, "autorpc-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, outPath string) error {
+	spec, err := readSpec(specPath)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	return autorpc.GenerateTypeScript(spec, out)
+}
+
+func readSpec(specPath string) (autorpc.ServerSpec, error) {
+	var spec autorpc.ServerSpec
+
+	var r io.Reader
+	switch {
+	case specPath == "-":
+		r = os.Stdin
+	case strings.HasPrefix(specPath, "http://"), strings.HasPrefix(specPath, "https://"):
+		resp, err := http.Get(specPath)
+		if err != nil {
+			return spec, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return spec, fmt.Errorf("fetching spec: unexpected status %s", resp.Status)
+		}
+		r = resp.Body
+	default:
+		f, err := os.Open(specPath)
+		if err != nil {
+			return spec, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	if err := json.NewDecoder(r).Decode(&spec); err != nil {
+		return spec, fmt.Errorf("decoding spec: %w", err)
+	}
+	return spec, nil
+}
@@ -0,0 +1,126 @@
+package autorpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// paramDefaults holds the `default` struct tags of a params type. They are applied to the params
+// before decoding, so fields omitted by the client keep their default value.
+// Map defaults are the exception: decoding merges into an existing map, so they are applied
+// after decoding, when the map is still nil (omitted or null).
+//
+// The tag value is parsed as JSON, or as a JSON string if it is not valid JSON for the field type:
+//
+//	Limit  int            `json:"limit" default:"25"`
+//	Order  string         `json:"order" default:"asc"`
+//	Tags   []string       `json:"tags" default:"[]"`
+//	Expiry types.Duration `json:"expiry" default:"1h"`
+//
+// Defaults of nested structs apply when the nested struct is not a pointer.
+type paramDefaults []fieldDefault
+
+type fieldDefault struct {
+	index []int           // index of the field, for reflect.Value.FieldByIndex
+	value reflect.Value   // decoded default, copied as is for scalar kinds
+	raw   json.RawMessage // default as JSON, decoded again for the other kinds to avoid sharing
+}
+
+// newParamDefaults collects the defaults of a params type, and returns an error if a default
+// cannot be decoded into its field.
+func newParamDefaults(paramType reflect.Type) (paramDefaults, error) {
+	if paramType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	var defaults paramDefaults
+	err := collectDefaults(paramType, nil, &defaults)
+	return defaults, err
+}
+
+func collectDefaults(typ reflect.Type, index []int, defaults *paramDefaults) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if tag, ok := field.Tag.Lookup("default"); ok {
+			value, raw, err := decodeDefault(tag, field.Type)
+			if err != nil {
+				return fmt.Errorf("invalid default %q for field %s.%s: %w", tag, typ, field.Name, err)
+			}
+			*defaults = append(*defaults, fieldDefault{index: fieldIndex, value: value, raw: raw})
+			continue
+		}
+
+		if field.Type.Kind() == reflect.Struct && !decodesItself(field.Type) && getUnmarshalKind(field.Type) == "" && field.Type != timeType {
+			if err := collectDefaults(field.Type, fieldIndex, defaults); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeDefault decodes the value of a default tag for a field of type typ.
+func decodeDefault(tag string, typ reflect.Type) (reflect.Value, json.RawMessage, error) {
+	ptr := reflect.New(typ)
+	if err := json.Unmarshal([]byte(tag), ptr.Interface()); err != nil {
+		quoted, _ := json.Marshal(tag)
+		if json.Unmarshal(quoted, ptr.Interface()) != nil {
+			return reflect.Value{}, nil, err
+		}
+	}
+
+	raw, err := json.Marshal(ptr.Interface())
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return ptr.Elem(), raw, nil
+}
+
+// fieldDefaultJSON returns the default of a struct field as JSON, or nil if it has none
+// or it is invalid.
+func fieldDefaultJSON(field reflect.StructField) json.RawMessage {
+	tag, ok := field.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+	_, raw, err := decodeDefault(tag, field.Type)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// apply sets the defaults on params, an addressable value of the params type, before decoding.
+// Map defaults are left to applyMaps.
+func (d paramDefaults) apply(params reflect.Value) {
+	for _, def := range d {
+		field := params.FieldByIndex(def.index)
+		switch field.Kind() {
+		case reflect.Map:
+			continue
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			field.Set(def.value)
+		default:
+			// Slices and pointers would be shared between requests if copied.
+			json.Unmarshal(def.raw, field.Addr().Interface())
+		}
+	}
+}
+
+// applyMaps sets the map defaults on params after decoding, for the maps the client did not set.
+func (d paramDefaults) applyMaps(params reflect.Value) {
+	for _, def := range d {
+		field := params.FieldByIndex(def.index)
+		if field.Kind() == reflect.Map && field.IsNil() {
+			json.Unmarshal(def.raw, field.Addr().Interface())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type defaultsParams struct {
+	Limit int            `json:"limit" default:"25"`
+	Tags  []string       `json:"tags" default:"[\"a\"]"`
+	Meta  map[string]int `json:"meta" default:"{\"x\":1}"`
+}
+
+func TestParamDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   defaultsParams
+	}{
+		{
+			name:   "omitted",
+			params: `{}`,
+			want:   defaultsParams{Limit: 25, Tags: []string{"a"}, Meta: map[string]int{"x": 1}},
+		},
+		{
+			name:   "map replaced",
+			params: `{"meta":{"y":2}}`,
+			want:   defaultsParams{Limit: 25, Tags: []string{"a"}, Meta: map[string]int{"y": 2}},
+		},
+		{
+			name:   "empty map",
+			params: `{"meta":{}}`,
+			want:   defaultsParams{Limit: 25, Tags: []string{"a"}, Meta: map[string]int{}},
+		},
+		{
+			name:   "null map",
+			params: `{"meta":null}`,
+			want:   defaultsParams{Limit: 25, Tags: []string{"a"}, Meta: map[string]int{"x": 1}},
+		},
+		{
+			name:   "set",
+			params: `{"limit":5,"tags":["b"]}`,
+			want:   defaultsParams{Limit: 5, Tags: []string{"b"}, Meta: map[string]int{"x": 1}},
+		},
+	}
+
+	server := NewServer()
+	RegisterMethod(server, "echo", func(ctx context.Context, p defaultsParams) (defaultsParams, error) {
+		return p, nil
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := server.processRequest(context.Background(), RPCRequest{
+				JSONRPC: "2.0",
+				Method:  "echo",
+				Params:  json.RawMessage(tt.params),
+				ID:      json.RawMessage(`1`),
+			})
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %+v", resp.Error)
+			}
+			if got := resp.Result.(defaultsParams); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Defaults must not be shared between requests.
+	first := server.processRequest(context.Background(), RPCRequest{JSONRPC: "2.0", Method: "echo", Params: json.RawMessage(`{}`), ID: json.RawMessage(`1`)})
+	first.Result.(defaultsParams).Meta["x"] = 100
+	second := server.processRequest(context.Background(), RPCRequest{JSONRPC: "2.0", Method: "echo", Params: json.RawMessage(`{}`), ID: json.RawMessage(`2`)})
+	if got := second.Result.(defaultsParams).Meta["x"]; got != 1 {
+		t.Errorf("default map shared between requests: x = %d", got)
+	}
+}
@@ -1,11 +1,30 @@
 package autorpc
 
+import "log"
+
 type RPCErrorProvider interface {
 	Code() int
 	Message() string
 	Data() interface{}
 }
 
+type rpcError struct {
+	code    int
+	message string
+	data    interface{}
+}
+
+// NewError returns an error implementing RPCErrorProvider with the given code, message and data.
+// Data can be nil.
+func NewError(code int, message string, data interface{}) error {
+	return &rpcError{code: code, message: message, data: data}
+}
+
+func (e *rpcError) Error() string     { return e.message }
+func (e *rpcError) Code() int         { return e.code }
+func (e *rpcError) Message() string   { return e.message }
+func (e *rpcError) Data() interface{} { return e.data }
+
 // errorToRPCError converts a Go error to an RPCError.
 // If the error implements RPCErrorProvider, it uses the custom code/message/data.
 // Otherwise, it defaults to CodeInternalError with the error message.
@@ -27,3 +46,18 @@ func errorToRPCError(err error) *RPCError {
 		Message: err.Error(),
 	}
 }
+
+// checkDeclaredError logs errors returned by a handler whose code was not declared with WithErrors.
+func checkDeclaredError(method string, handler *methodHandler, err error) {
+	provider, ok := err.(RPCErrorProvider)
+	if !ok {
+		return
+	}
+
+	for _, declared := range handler.metadata.errors {
+		if declared.Code() == provider.Code() {
+			return
+		}
+	}
+	log.Printf("autorpc: method %q returned undeclared error code %d (%s)", method, provider.Code(), provider.Message())
+}
@@ -85,6 +85,7 @@ func main() {
 
 	http.Handle("/rpc", autorpc.HTTPHandler(server))
 	http.Handle("/spec.json", autorpc.SpecJSONHandler(server))
+	http.Handle("/openrpc.json", autorpc.OpenRPCHandler(server))
 
 	log.Println("Server started on port 8080")
 	http.ListenAndServe(":8080", nil)
@@ -72,14 +72,9 @@ func (s *MathService) Fibonacci(ctx context.Context, num int) (int, error) {
 func main() {
 	server := autorpc.NewServer()
 
-	mathService := &MathService{}
-	autorpc.RegisterMethod(server, "math.add", mathService.Add)
-	autorpc.RegisterMethod(server, "math.subtract", mathService.Subtract)
-	autorpc.RegisterMethod(server, "math.multiply", mathService.Multiply)
-	autorpc.RegisterMethod(server, "math.divide", mathService.Divide)
-	autorpc.RegisterMethod(server, "math.sum", mathService.Sum)
-	autorpc.RegisterMethod(server, "math.factorial", mathService.Factorial)
-	autorpc.RegisterMethod(server, "math.fibonacci", mathService.Fibonacci)
+	if err := autorpc.RegisterService(server, "math.", &MathService{}); err != nil {
+		log.Fatal(err)
+	}
 
 	http.Handle("/rpc", autorpc.HTTPHandler(server))
 	http.Handle("/spec", autorpc.SpecUIHandler(server))
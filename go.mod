@@ -2,7 +2,10 @@ module github.com/Lexographics/autorpc
 
 go 1.24.9
 
-require github.com/go-playground/validator/v10 v10.22.1
+require (
+	github.com/go-playground/validator/v10 v10.22.1
+	github.com/gorilla/websocket v1.5.3
+)
 
 require (
 	github.com/gabriel-vasile/mimetype v1.4.3 // indirect
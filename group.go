@@ -31,11 +31,19 @@ func (g *Group) Use(middlewares ...Middleware) {
 	}
 }
 
+func (g *Group) rootServer() *Server {
+	return g.server
+}
+
+func (g *Group) methodName(name string) string {
+	return g.prefix + name
+}
+
 // register implements the Registerer interface for Group.
 // It combines the group prefix with the method name and combines
 // group middlewares with method-specific middlewares before delegating to the server.
-func (g *Group) register(name string, fn interface{}, methodMiddlewares *MiddlewareChain) {
-	fullName := g.prefix + name
+func (g *Group) register(name string, fn interface{}, options *methodOptions) {
+	fullName := g.methodName(name)
 	allMiddlewares := NewMiddlewareChain()
 
 	if g.middlewares != nil && g.middlewares.Len() > 0 {
@@ -44,11 +52,13 @@ func (g *Group) register(name string, fn interface{}, methodMiddlewares *Middlew
 		}
 	}
 
-	if methodMiddlewares != nil && methodMiddlewares.Len() > 0 {
-		for i := 0; i < methodMiddlewares.Len(); i++ {
-			allMiddlewares.Add(methodMiddlewares.middlewares[i])
+	if options.middlewares != nil && options.middlewares.Len() > 0 {
+		for i := 0; i < options.middlewares.Len(); i++ {
+			allMiddlewares.Add(options.middlewares.middlewares[i])
 		}
 	}
 
-	g.server.register(fullName, fn, allMiddlewares)
+	groupOptions := *options
+	groupOptions.middlewares = allMiddlewares
+	g.server.register(fullName, fn, &groupOptions)
 }
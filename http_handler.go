@@ -1,11 +1,12 @@
 package autorpc
 
 import (
-	"bytes"
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
-	"sync"
 )
 
 func HTTPHandler(server *Server) http.Handler {
@@ -14,55 +15,113 @@ func HTTPHandler(server *Server) http.Handler {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
+		defer r.Body.Close()
+
+		body := r.Body
+		if server.maxBodyBytes > 0 {
+			body = http.MaxBytesReader(w, body, server.maxBodyBytes)
+		}
+		br := bufio.NewReader(body)
 
-		body, err := io.ReadAll(r.Body)
+		first, err := peekNonSpace(br)
 		if err != nil {
-			resp := newErrorResponse(nil, CodeParseError, "Failed to read body")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+			if errors.Is(err, io.EOF) {
+				writeHTTPError(w, http.StatusBadRequest, newErrorResponse(nil, CodeInvalidRequest, "Empty request body"))
+				return
+			}
+			writeHTTPReadError(w, err)
 			return
 		}
-		defer r.Body.Close()
-
-		trimmedBody := bytes.TrimSpace(body)
 
-		if len(trimmedBody) == 0 {
-			resp := newErrorResponse(nil, CodeInvalidRequest, "Empty request body")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
-			return
+		// Notifications are sent as Server-Sent Events if the client accepts them
+		var stream *sseStream
+		if wantsEventStream(r) {
+			stream = &sseStream{w: w}
 		}
 
 		// starts with '[' -> batch
 		// starts with '{' -> single
-		if trimmedBody[0] == '[' {
-			handleBatchHTTP(server, w, r, trimmedBody)
-		} else if trimmedBody[0] == '{' {
-			handleSingleHTTP(server, w, r, trimmedBody)
+		if first == '[' {
+			handleBatchHTTP(server, w, r, br, stream)
+		} else if first == '{' {
+			body, err := io.ReadAll(br)
+			if err != nil {
+				writeHTTPReadError(w, err)
+				return
+			}
+			handleSingleHTTP(server, w, r, body, stream)
 		} else {
-			resp := newErrorResponse(nil, CodeParseError, "Invalid JSON")
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(resp)
+			writeHTTPError(w, http.StatusBadRequest, newErrorResponse(nil, CodeParseError, "Invalid JSON"))
 		}
 	})
 }
 
-func handleSingleHTTP(server *Server, w http.ResponseWriter, r *http.Request, body []byte) {
-	var req RPCRequest
-	if err := json.Unmarshal(body, &req); err != nil {
-		resp := newErrorResponse(nil, CodeParseError, "Failed to parse JSON request")
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+// peekNonSpace skips leading JSON whitespace and returns the next byte without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
+
+// bodyTooLargeResponse is the response to a request larger than Server.SetMaxBodyBytes.
+func bodyTooLargeResponse(limit int64) RPCResponse {
+	resp := newErrorResponse(nil, CodeInvalidRequest, "Request body too large")
+	resp.Error.Data = map[string]int64{"maxBytes": limit}
+	return resp
+}
+
+// writeHTTPReadError replies to a request whose body could not be read.
+func writeHTTPReadError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeHTTPError(w, http.StatusRequestEntityTooLarge, bodyTooLargeResponse(maxBytesErr.Limit))
 		return
 	}
+	writeHTTPError(w, http.StatusBadRequest, newErrorResponse(nil, CodeParseError, "Failed to read body"))
+}
 
+func writeHTTPError(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// httpContext returns the context of the requests of r.
+func httpContext(r *http.Request, stream *sseStream) (context.Context, context.CancelFunc) {
 	ctx := WithHTTPRequest(r.Context(), r)
+	if stream != nil {
+		ctx = withNotifier(ctx, stream)
+	}
+	return withTimeoutHeader(ctx, r)
+}
+
+func handleSingleHTTP(server *Server, w http.ResponseWriter, r *http.Request, body []byte, stream *sseStream) {
+	if !json.Valid(body) {
+		writeHTTPError(w, http.StatusBadRequest, newErrorResponse(nil, CodeParseError, "Failed to parse JSON request"))
+		return
+	}
+	req, errResp := parseRequest(body)
+	if errResp != nil {
+		writeHTTPError(w, http.StatusBadRequest, *errResp)
+		return
+	}
+
+	ctx, cancel := httpContext(r, stream)
+	defer cancel()
 	resp := server.processRequest(ctx, req)
 
+	if stream != nil && stream.finish(resp, req.ID != nil) {
+		return
+	}
+
 	// If req.ID is nil, it's a Notification.
 	// 4.1 Notification: "The Server MUST NOT reply to a Notification"
 	if req.ID == nil {
@@ -75,46 +134,60 @@ func handleSingleHTTP(server *Server, w http.ResponseWriter, r *http.Request, bo
 	json.NewEncoder(w).Encode(resp)
 }
 
-func handleBatchHTTP(server *Server, w http.ResponseWriter, r *http.Request, body []byte) {
-	var reqs []RPCRequest
-	if err := json.Unmarshal(body, &reqs); err != nil {
-		resp := newErrorResponse(nil, CodeParseError, "Failed to parse JSON batch")
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
-		return
-	}
+// errTrailingData is returned when a batch body has data after the closing bracket.
+var errTrailingData = errors.New("unexpected data after batch")
 
-	if len(reqs) == 0 {
-		resp := newErrorResponse(nil, CodeInvalidRequest, "Empty batch")
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(resp)
+// handleBatchHTTP decodes the batch in body element by element. With BatchOptions.Stream,
+// requests are processed while the rest of the batch is still being read.
+func handleBatchHTTP(server *Server, w http.ResponseWriter, r *http.Request, body io.Reader, stream *sseStream) {
+	dec := json.NewDecoder(body)
+	if _, err := dec.Token(); err != nil { // '['
+		writeHTTPReadError(w, err)
 		return
 	}
 
-	ctx := WithHTTPRequest(r.Context(), r)
-	responses := make([]RPCResponse, 0, len(reqs))
-	var responsesMu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, req := range reqs {
-		wg.Add(1)
-		go func(r RPCRequest) {
-			defer wg.Done()
-
-			resp := server.processRequest(ctx, r)
-
-			// 4.1 Notification: "The Server MUST NOT reply to a Notification"
-			if r.ID != nil {
-				responsesMu.Lock()
-				responses = append(responses, resp)
-				responsesMu.Unlock()
+	next := func() (json.RawMessage, bool, error) {
+		if !dec.More() {
+			// ']'
+			if _, err := dec.Token(); err != nil {
+				return nil, false, err
 			}
-		}(req)
+			// Only whitespace may follow
+			if _, err := dec.Token(); err != io.EOF {
+				if err == nil {
+					err = errTrailingData
+				}
+				return nil, false, err
+			}
+			return nil, false, nil
+		}
+		var element json.RawMessage
+		if err := dec.Decode(&element); err != nil {
+			return nil, false, err
+		}
+		return element, true, nil
 	}
 
-	wg.Wait()
+	ctx, cancel := httpContext(r, stream)
+	defer cancel()
+	responses, err := server.processBatchStream(ctx, next)
+	if err != nil {
+		resp, status := server.batchErrorResponse(err)
+		// Requests dispatched before the error was found have run, so their responses come first
+		var body interface{} = resp
+		if len(responses) > 0 {
+			body = append(responses, resp)
+		}
+		if stream != nil && stream.finish(body, true) {
+			return
+		}
+		writeHTTPError(w, status, body)
+		return
+	}
+
+	if stream != nil && stream.finish(responses, len(responses) > 0) {
+		return
+	}
 
 	// If the batch only contains notifications, we must not return an empty array
 	if len(responses) == 0 {
@@ -0,0 +1,107 @@
+package autorpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postHTTP(handler http.Handler, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHTTPHandler(t *testing.T) {
+	handler := HTTPHandler(newTestServer())
+
+	for _, tt := range protocolTests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postHTTP(handler, tt.body, nil)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.want == "" {
+				if rec.Body.Len() != 0 {
+					t.Errorf("unexpected body %s", rec.Body)
+				}
+				return
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			assertResponses(t, rec.Body.Bytes(), tt.want)
+		})
+	}
+}
+
+func TestHTTPHandlerRejectedRequests(t *testing.T) {
+	server := newTestServer()
+	server.SetMaxBodyBytes(64)
+	handler := HTTPHandler(server)
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+		want   string
+	}{
+		{name: "GET", method: http.MethodGet, status: http.StatusMethodNotAllowed},
+		{name: "empty body", method: http.MethodPost, body: "  ", status: http.StatusBadRequest, want: `{"jsonrpc":"2.0","error":{"code":-32600},"id":null}`},
+		{name: "not JSON", method: http.MethodPost, body: "hello", status: http.StatusBadRequest, want: `{"jsonrpc":"2.0","error":{"code":-32700},"id":null}`},
+		{name: "trailing data", method: http.MethodPost, body: `{"jsonrpc":"2.0","method":"echo","params":"a","id":1} x`, status: http.StatusBadRequest, want: `{"jsonrpc":"2.0","error":{"code":-32700},"id":null}`},
+		{name: "too large", method: http.MethodPost, body: `{"jsonrpc":"2.0","method":"echo","params":"` + strings.Repeat("a", 64) + `","id":1}`, status: http.StatusRequestEntityTooLarge, want: `{"jsonrpc":"2.0","error":{"code":-32600},"id":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.want != "" {
+				assertResponses(t, rec.Body.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPHandlerCancellation(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := newTestServer()
+	RegisterMethod(server, "block", func(ctx context.Context, p EmptyParams) (bool, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+	handler := HTTPHandler(server)
+
+	t.Run("client gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"block","id":1}`)).WithContext(ctx)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+
+		<-started
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler not cancelled when the client went away")
+		}
+	})
+}
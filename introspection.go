@@ -0,0 +1,67 @@
+package autorpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// reservedPrefix starts the names of the methods reserved by the JSON-RPC 2.0 specification.
+// User methods cannot be registered with it.
+const reservedPrefix = "rpc."
+
+// EmptyParams are the params of methods that take none. Params can be omitted when calling them.
+type EmptyParams struct{}
+
+var emptyParamsType = reflect.TypeOf(EmptyParams{})
+
+// MethodSignatureParams are the params of system.methodSignature.
+type MethodSignatureParams struct {
+	Method string `json:"method" validate:"required"`
+}
+
+// EnableIntrospection registers methods describing the server, so clients speaking only JSON-RPC
+// can discover the API without the SpecJSONHandler and OpenRPCHandler endpoints:
+//   - rpc.discover: returns the OpenRPC document of the server
+//   - system.listMethods: returns the sorted names of the methods
+//   - system.methodSignature({"method"}): returns the MethodInfo of a method, as in the spec
+//
+// Global middlewares added with Use before the call apply to these methods.
+func (s *Server) EnableIntrospection() {
+	reserved := methodOptionFunc(func(o *methodOptions) {
+		o.reserved = true
+	})
+
+	RegisterMethodWithOptions(s, "rpc.discover", func(ctx context.Context, params EmptyParams) (OpenRPCDocument, error) {
+		return s.OpenRPCDocument(), nil
+	}, reserved, WithDescription("Returns the OpenRPC document of the server."), WithTags("system"))
+
+	RegisterMethodWithOptions(s, "system.listMethods", func(ctx context.Context, params EmptyParams) ([]string, error) {
+		var names []string
+		s.methods.Range(func(key, value any) bool {
+			names = append(names, key.(string))
+			return true
+		})
+		sort.Strings(names)
+		return names, nil
+	}, WithDescription("Returns the names of the methods of the server."), WithTags("system"))
+
+	RegisterMethodWithOptions(s, "system.methodSignature", func(ctx context.Context, params MethodSignatureParams) (MethodInfo, error) {
+		for _, method := range s.GetMethodSpecs().Methods {
+			if method.Name == params.Method {
+				return method, nil
+			}
+		}
+		return MethodInfo{}, NewError(CodeInvalidParams, "Method not found", map[string]string{"method": params.Method})
+	}, WithDescription("Returns the signature and documentation of a method."), WithTags("system"))
+}
+
+// checkMethodName returns an error if name cannot be used by a user method.
+func checkMethodName(name string) error {
+	if strings.HasPrefix(name, reservedPrefix) {
+		return fmt.Errorf("method name %q is reserved: names starting with %q are reserved by the JSON-RPC specification", name, reservedPrefix)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package autorpc
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+type noFieldsParams struct{}
+
+func TestIntrospectionParams(t *testing.T) {
+	server := newTestServer()
+	RegisterMethod(server, "noFields", func(ctx context.Context, p noFieldsParams) (bool, error) {
+		return true, nil
+	})
+	server.EnableIntrospection()
+
+	tests := []struct {
+		name     string
+		method   string
+		params   string
+		wantCode int
+	}{
+		{name: "discover without params", method: "rpc.discover"},
+		{name: "discover with empty array", method: "rpc.discover", params: `[]`},
+		{name: "discover with empty object", method: "rpc.discover", params: `{}`},
+		{name: "discover with positional param", method: "rpc.discover", params: `[1]`, wantCode: CodeInvalidParams},
+		{name: "list methods with empty array", method: "system.listMethods", params: `[]`},
+		{name: "struct without fields with empty array", method: "noFields", params: `[]`},
+		{name: "struct without fields omitted", method: "noFields", wantCode: CodeInvalidParams},
+		{name: "struct params omitted", method: "add", wantCode: CodeInvalidParams},
+		{name: "array params stay arrays", method: "echo", params: `[]`, wantCode: CodeInvalidParams},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callJSON(server, tt.method, tt.params)
+			switch {
+			case tt.wantCode == 0 && resp.Error != nil:
+				t.Fatalf("unexpected error: %+v", resp.Error)
+			case tt.wantCode != 0 && (resp.Error == nil || resp.Error.Code != tt.wantCode):
+				t.Fatalf("error = %+v, want code %d", resp.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+type reservedService struct{}
+
+func (reservedService) Discover(ctx context.Context, p EmptyParams) (bool, error) {
+	return true, nil
+}
+
+func TestRegisterServiceReservedName(t *testing.T) {
+	tests := []struct {
+		name     string
+		register func(server *Server) error
+	}{
+		{name: "prefix", register: func(server *Server) error {
+			return RegisterService(server, "rpc.", reservedService{})
+		}},
+		{name: "group prefix", register: func(server *Server) error {
+			return RegisterService(server.Group("rpc."), "", reservedService{})
+		}},
+		{name: "group and service prefix", register: func(server *Server) error {
+			return RegisterService(server.Group("rp"), "c.", reservedService{})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			err := tt.register(server)
+			if err == nil || !strings.Contains(err.Error(), "reserved") {
+				t.Fatalf("RegisterService error = %v, want reserved name", err)
+			}
+			if _, ok := server.methods.Load("rpc.discover"); ok {
+				t.Error("reserved method registered")
+			}
+		})
+	}
+}
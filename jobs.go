@@ -0,0 +1,394 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of an asynchronous job.
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"   // waiting for a free slot
+	JobRunning   JobStatus = "running"   // the handler is running
+	JobSucceeded JobStatus = "succeeded" // the result is available with jobs.result
+	JobFailed    JobStatus = "failed"    // the error is available with jobs.result
+	JobCancelled JobStatus = "cancelled" // cancelled with jobs.cancel
+)
+
+// Job is an asynchronous call of a method registered with RegisterAsyncMethod.
+type Job struct {
+	ID         string          `json:"id"`
+	Method     string          `json:"method"`
+	Status     JobStatus       `json:"status"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      *RPCError       `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	StartedAt  *time.Time      `json:"startedAt,omitempty"`
+	FinishedAt *time.Time      `json:"finishedAt,omitempty"`
+}
+
+// Done reports whether the job reached a final status.
+func (j Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+// ErrJobNotFound is returned by JobStore.Get when there is no job with the given id.
+var ErrJobNotFound = errors.New("autorpc: job not found")
+
+// JobStore persists the jobs of asynchronous methods. Implementations must be safe for concurrent use.
+// The running handlers and their cancellation stay in the server process; the store only keeps
+// the state of the jobs, so a shared store lets any instance answer jobs.status and jobs.result.
+type JobStore interface {
+	// Create stores a new job.
+	Create(ctx context.Context, job Job) error
+	// Update replaces a stored job.
+	Update(ctx context.Context, job Job) error
+	// Get returns the job with the given id, or ErrJobNotFound.
+	Get(ctx context.Context, id string) (Job, error)
+	// Delete removes a job. Deleting a missing job is not an error.
+	// The server deletes finished jobs after Server.SetJobRetention.
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryJobStore is a JobStore keeping jobs in memory, until the server deletes them
+// after Server.SetJobRetention.
+type MemoryJobStore struct {
+	mu   sync.RWMutex
+	jobs map[string]Job
+}
+
+// NewMemoryJobStore returns an empty MemoryJobStore. It is the default JobStore of a server.
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemoryJobStore) Create(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryJobStore) Update(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; !ok {
+		return ErrJobNotFound
+	}
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryJobStore) Get(ctx context.Context, id string) (Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrJobNotFound
+	}
+	return job, nil
+}
+
+func (s *MemoryJobStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// Error codes of the jobs methods.
+const (
+	CodeJobNotFound    = -32002
+	CodeJobNotFinished = -32003
+	CodeJobQueueFull   = -32004
+)
+
+const (
+	defaultJobRetention = time.Hour
+	defaultJobQueueSize = 1000
+)
+
+// JobIDParams are the params of the jobs.status, jobs.result and jobs.cancel methods.
+type JobIDParams struct {
+	ID string `json:"id" validate:"required"`
+}
+
+// jobManager runs the jobs of a server.
+type jobManager struct {
+	store       JobStore
+	concurrency int
+	queueSize   int
+	retention   time.Duration
+	options     []MethodOption // options of the jobs.* methods
+	slots       chan struct{}
+
+	registerOnce sync.Once
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // jobs pending or running in this process
+}
+
+// SetJobStore sets the store of the jobs of asynchronous methods. The default is a MemoryJobStore.
+// It must be called before RegisterAsyncMethod.
+func (s *Server) SetJobStore(store JobStore) {
+	s.jobs.store = store
+}
+
+// SetJobConcurrency sets the maximum number of jobs running at the same time. Other jobs stay
+// pending until a slot is free. The default is runtime.NumCPU(). It must be called before
+// RegisterAsyncMethod.
+func (s *Server) SetJobConcurrency(n int) {
+	s.jobs.concurrency = n
+}
+
+// SetJobQueueSize sets the maximum number of jobs waiting for a free slot. When the queue is full,
+// asynchronous methods fail with CodeJobQueueFull instead of creating a job. The default is 1000.
+// It must be called before RegisterAsyncMethod.
+func (s *Server) SetJobQueueSize(n int) {
+	s.jobs.queueSize = n
+}
+
+// SetJobRetention sets how long finished jobs are kept before they are deleted from the store.
+// Their result can be fetched with jobs.result until then. The default is one hour.
+// It must be called before RegisterAsyncMethod.
+func (s *Server) SetJobRetention(retention time.Duration) {
+	s.jobs.retention = retention
+}
+
+// SetJobMethodOptions sets options of the jobs.* methods, in addition to the global middlewares.
+// These methods are registered on the server itself, even when asynchronous methods are registered
+// on a Group, so they do not run the middlewares of the group. Pass them here to protect jobs.*
+// in the same way, for example with an authentication middleware:
+//
+//	api := server.Group("api.", AuthMiddleware())
+//	server.SetJobMethodOptions(AuthMiddleware())
+//	autorpc.RegisterAsyncMethod(api, "reports.build", BuildReport)
+//
+// It must be called before RegisterAsyncMethod.
+func (s *Server) SetJobMethodOptions(opts ...MethodOption) {
+	s.jobs.options = opts
+}
+
+// init completes the configuration of the manager. It is called at registration.
+func (m *jobManager) init() {
+	if m.store == nil {
+		m.store = NewMemoryJobStore()
+	}
+	if m.concurrency <= 0 {
+		m.concurrency = runtime.NumCPU()
+	}
+	if m.queueSize <= 0 {
+		m.queueSize = defaultJobQueueSize
+	}
+	if m.retention <= 0 {
+		m.retention = defaultJobRetention
+	}
+	m.slots = make(chan struct{}, m.concurrency)
+	m.cancels = make(map[string]context.CancelFunc)
+}
+
+// RegisterAsyncMethod registers a method that returns a Job immediately and runs fn in the background.
+// Params are decoded and validated, and middlewares run, before the job is created.
+// At most Server.SetJobConcurrency jobs run at the same time, and at most Server.SetJobQueueSize
+// wait for a slot. Finished jobs are deleted after Server.SetJobRetention.
+//
+// The first call also registers, on the server:
+//   - jobs.status({"id"}): returns the Job, without its result
+//   - jobs.result({"id"}): returns the result of a succeeded job, or the error of a failed one
+//   - jobs.cancel({"id"}): cancels the context of a pending or running job, and reports whether it did
+//
+// These methods are registered on the root server even if r is a Group, and only run the global
+// middlewares and the options set with Server.SetJobMethodOptions.
+//
+// Example:
+//
+//	autorpc.RegisterAsyncMethod(server, "reports.build", BuildReport)
+//	// -> {"id": "3f2a...", "method": "reports.build", "status": "pending", ...}
+func RegisterAsyncMethod[P, R any](
+	r Registerer,
+	name string,
+	fn func(context.Context, P) (R, error),
+	opts ...MethodOption,
+) {
+	server := r.rootServer()
+	jobs := &server.jobs
+	jobs.registerOnce.Do(func() {
+		jobs.init()
+		registerJobMethods(server)
+	})
+
+	start := func(ctx context.Context, params P) (Job, error) {
+		method := name
+		if req, ok := rpcRequestFromContext(ctx); ok {
+			method = req.Method
+		}
+
+		job := Job{
+			ID:        newRandomID(),
+			Method:    method,
+			Status:    JobPending,
+			CreatedAt: time.Now(),
+		}
+
+		// The job outlives the request, but keeps its values (transport, middleware data).
+		jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		if !jobs.add(job.ID, cancel) {
+			cancel()
+			return Job{}, NewError(CodeJobQueueFull, "Job queue full", map[string]int{"queueSize": jobs.queueSize})
+		}
+		if err := jobs.store.Create(ctx, job); err != nil {
+			jobs.cancel(job.ID)
+			return Job{}, err
+		}
+
+		go jobs.run(jobCtx, job, func(ctx context.Context) (interface{}, error) {
+			return fn(ctx, params)
+		})
+		return job, nil
+	}
+
+	RegisterMethodWithOptions(r, name, start, opts...)
+}
+
+// add registers a new job, unless as many jobs as slots and queued jobs are already active.
+func (m *jobManager) add(id string, cancel context.CancelFunc) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.cancels) >= m.concurrency+m.queueSize {
+		return false
+	}
+	m.cancels[id] = cancel
+	return true
+}
+
+// run waits for a free slot, runs fn and stores the outcome of the job.
+func (m *jobManager) run(ctx context.Context, job Job, fn func(context.Context) (interface{}, error)) {
+	defer func() {
+		m.mu.Lock()
+		cancel := m.cancels[job.ID]
+		delete(m.cancels, job.ID)
+		m.mu.Unlock()
+		if cancel != nil {
+			cancel()
+		}
+	}()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-ctx.Done():
+		m.finish(job, nil, ctx.Err())
+		return
+	}
+
+	now := time.Now()
+	job.Status = JobRunning
+	job.StartedAt = &now
+	m.store.Update(context.WithoutCancel(ctx), job)
+
+	result, err := func() (result interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return fn(ctx)
+	}()
+	m.finish(job, result, err)
+}
+
+// finish stores the final status of a job, and deletes it from the store after the retention.
+func (m *jobManager) finish(job Job, result interface{}, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+
+	m.mu.Lock()
+	_, active := m.cancels[job.ID]
+	m.mu.Unlock()
+
+	switch {
+	case err != nil && !active:
+		// Removed from cancels by jobs.cancel
+		job.Status = JobCancelled
+		job.Error = &RPCError{Code: CodeRequestCancelled, Message: "Job cancelled"}
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = errorToRPCError(err)
+	default:
+		data, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			job.Status = JobFailed
+			job.Error = &RPCError{Code: CodeInternalError, Message: "Failed to encode result: " + marshalErr.Error()}
+			break
+		}
+		job.Status = JobSucceeded
+		job.Result = data
+	}
+
+	m.store.Update(context.Background(), job)
+	time.AfterFunc(m.retention, func() {
+		m.store.Delete(context.Background(), job.ID)
+	})
+}
+
+// cancel cancels a job pending or running in this process. It reports whether the job was found.
+func (m *jobManager) cancel(id string) bool {
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	delete(m.cancels, id)
+	m.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// registerJobMethods registers the jobs.* methods on server, with the options of server.jobs.
+func registerJobMethods(server *Server) {
+	withOptions := func(opts ...MethodOption) []MethodOption {
+		return append(opts, server.jobs.options...)
+	}
+
+	getJob := func(ctx context.Context, id string) (Job, error) {
+		job, err := server.jobs.store.Get(ctx, id)
+		if errors.Is(err, ErrJobNotFound) {
+			return Job{}, NewError(CodeJobNotFound, "Job not found", nil)
+		}
+		return job, err
+	}
+
+	RegisterMethodWithOptions(server, "jobs.status", func(ctx context.Context, params JobIDParams) (Job, error) {
+		job, err := getJob(ctx, params.ID)
+		job.Result = nil
+		return job, err
+	}, withOptions(WithDescription("Returns the status of an asynchronous job."), WithTags("jobs"))...)
+
+	RegisterMethodWithOptions(server, "jobs.result", func(ctx context.Context, params JobIDParams) (json.RawMessage, error) {
+		job, err := getJob(ctx, params.ID)
+		if err != nil {
+			return nil, err
+		}
+		switch job.Status {
+		case JobSucceeded:
+			return job.Result, nil
+		case JobFailed, JobCancelled:
+			return nil, NewError(job.Error.Code, job.Error.Message, job.Error.Data)
+		default:
+			return nil, NewError(CodeJobNotFinished, "Job not finished", map[string]JobStatus{"status": job.Status})
+		}
+	}, withOptions(WithDescription("Returns the result of a finished asynchronous job, or its error."), WithTags("jobs"))...)
+
+	RegisterMethodWithOptions(server, "jobs.cancel", func(ctx context.Context, params JobIDParams) (bool, error) {
+		if _, err := getJob(ctx, params.ID); err != nil {
+			return false, err
+		}
+		return server.jobs.cancel(params.ID), nil
+	}, withOptions(WithDescription("Cancels a pending or running asynchronous job. Returns false if it already finished."), WithTags("jobs"))...)
+}
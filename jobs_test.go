@@ -0,0 +1,135 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// callJSON processes a request and returns its response.
+func callJSON(server *Server, method string, params string) RPCResponse {
+	return server.processRequest(context.Background(), RPCRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  json.RawMessage(params),
+		ID:      json.RawMessage(`1`),
+	})
+}
+
+// waitJob polls jobs.status until the job reaches status.
+func waitJob(t *testing.T, server *Server, id string, status JobStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		resp := callJSON(server, "jobs.status", `{"id":"`+id+`"}`)
+		if resp.Error == nil && resp.Result.(Job).Status == status {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach status %s", id, status)
+}
+
+func TestAsyncMethodQueue(t *testing.T) {
+	server := NewServer()
+	server.SetJobConcurrency(1)
+	server.SetJobQueueSize(1)
+	release := make(chan struct{})
+	RegisterAsyncMethod(server, "work", func(ctx context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	})
+
+	running := callJSON(server, "work", `1`).Result.(Job)
+	waitJob(t, server, running.ID, JobRunning)
+	pending := callJSON(server, "work", `2`).Result.(Job)
+
+	resp := callJSON(server, "work", `3`)
+	if resp.Error == nil || resp.Error.Code != CodeJobQueueFull {
+		t.Fatalf("third job: error = %+v, want code %d", resp.Error, CodeJobQueueFull)
+	}
+
+	// A cancelled job frees its place in the queue
+	if resp := callJSON(server, "jobs.cancel", `{"id":"`+pending.ID+`"}`); resp.Result != true {
+		t.Fatalf("jobs.cancel = %+v, want true", resp)
+	}
+	waitJob(t, server, pending.ID, JobCancelled)
+	queued := callJSON(server, "work", `4`)
+	if queued.Error != nil {
+		t.Fatalf("job after cancel: %+v", queued.Error)
+	}
+
+	close(release)
+	waitJob(t, server, running.ID, JobSucceeded)
+	waitJob(t, server, queued.Result.(Job).ID, JobSucceeded)
+	if resp := callJSON(server, "jobs.result", `{"id":"`+running.ID+`"}`); string(resp.Result.(json.RawMessage)) != "1" {
+		t.Errorf("jobs.result = %+v, want 1", resp)
+	}
+}
+
+func TestAsyncMethodRetention(t *testing.T) {
+	server := NewServer()
+	server.SetJobRetention(20 * time.Millisecond)
+	RegisterAsyncMethod(server, "work", func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	})
+
+	job := callJSON(server, "work", `1`).Result.(Job)
+	waitJob(t, server, job.ID, JobSucceeded)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp := callJSON(server, "jobs.result", `{"id":"`+job.ID+`"}`)
+		if resp.Error != nil && resp.Error.Code == CodeJobNotFound {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("finished job not deleted: %+v", resp)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobMethodOptions(t *testing.T) {
+	type authKey struct{}
+	auth := func(ctx context.Context, req RPCRequest, next HandlerFunc) (RPCResponse, error) {
+		if ctx.Value(authKey{}) == nil {
+			return newErrorResponse(req.ID, 401, "Unauthorized"), nil
+		}
+		return next(ctx, req)
+	}
+
+	server := NewServer()
+	server.SetJobMethodOptions(Middleware(auth), WithDeprecated("poll api.work.status instead"))
+	api := server.Group("api.", auth)
+	RegisterAsyncMethod(api, "work", func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	})
+
+	authorized := context.WithValue(context.Background(), authKey{}, true)
+	call := func(ctx context.Context, method, params string) RPCResponse {
+		return server.processRequest(ctx, RPCRequest{JSONRPC: "2.0", Method: method, Params: json.RawMessage(params), ID: json.RawMessage(`1`)})
+	}
+
+	if resp := call(context.Background(), "api.work", `1`); resp.Error == nil || resp.Error.Code != 401 {
+		t.Fatalf("api.work without auth = %+v, want 401", resp)
+	}
+	job := call(authorized, "api.work", `1`).Result.(Job)
+
+	for _, method := range []string{"jobs.status", "jobs.result", "jobs.cancel"} {
+		params := `{"id":"` + job.ID + `"}`
+		if resp := call(context.Background(), method, params); resp.Error == nil || resp.Error.Code != 401 {
+			t.Errorf("%s without auth = %+v, want 401", method, resp)
+		}
+		if resp := call(authorized, method, params); resp.Error != nil && resp.Error.Code == 401 {
+			t.Errorf("%s with auth = %+v, want authorized", method, resp.Error)
+		}
+	}
+
+	for _, method := range server.GetMethodSpecs().Methods {
+		if method.Name == "jobs.status" && (!method.Deprecated || method.Description == "") {
+			t.Errorf("jobs.status = %+v, want its description and the deprecation", method)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package autorpc
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchema is a JSON Schema document or subschema.
+// Only the keywords autorpc can derive from Go types and validate tags are included.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Default              json.RawMessage        `json:"default,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	ContentEncoding      string                 `json:"contentEncoding,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Const                interface{}            `json:"const,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	MinItems             *int                   `json:"minItems,omitempty"`
+	MaxItems             *int                   `json:"maxItems,omitempty"`
+	MinProperties        *int                   `json:"minProperties,omitempty"`
+	MaxProperties        *int                   `json:"maxProperties,omitempty"`
+	UniqueItems          bool                   `json:"uniqueItems,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// JSONSchemaDraft is the JSON Schema dialect of the documents returned by JSONSchemaFor.
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaFor returns a standalone JSON Schema (draft 2020-12) document for typ.
+// Named struct types are emitted in $defs and referenced with $ref, pointers are nullable,
+// maps are objects with additionalProperties, and fields with a "required" validate tag are required.
+// Types implementing UnmarshalKind use that kind, with the format given by JSONSchemaFormat if implemented.
+//
+// Example:
+//
+//	schema := autorpc.JSONSchemaFor(reflect.TypeOf(CreateUserParams{}))
+func JSONSchemaFor(typ reflect.Type) *JSONSchema {
+	builder := newSchemaBuilder("#/$defs/", false)
+	schema := builder.schemaFor(typ)
+	schema.Schema = JSONSchemaDraft
+	if len(builder.defs) > 0 {
+		schema.Defs = builder.defs
+	}
+	return schema
+}
+
+var (
+	timeType             = reflect.TypeOf(time.Time{})
+	jsonSchemaFormatType = reflect.TypeOf((*JSONSchemaFormat)(nil)).Elem()
+)
+
+// schemaBuilder converts Go types to JSON Schemas. Named struct types are emitted once
+// in defs and referenced with refPrefix + name, which allows recursive types.
+// If qualified is set, defs are named like the types of ServerSpec, otherwise with short "pkg.Type" names.
+type schemaBuilder struct {
+	refPrefix string
+	qualified bool
+	defs      map[string]*JSONSchema
+	names     map[reflect.Type]string
+}
+
+func newSchemaBuilder(refPrefix string, qualified bool) *schemaBuilder {
+	return &schemaBuilder{
+		refPrefix: refPrefix,
+		qualified: qualified,
+		defs:      make(map[string]*JSONSchema),
+		names:     make(map[reflect.Type]string),
+	}
+}
+
+func (b *schemaBuilder) schemaFor(typ reflect.Type) *JSONSchema {
+	if typ.Kind() == reflect.Ptr {
+		return nullableSchema(b.schemaFor(typ.Elem()))
+	}
+
+	if unmarshalKind := getUnmarshalKind(typ); unmarshalKind != "" {
+		return &JSONSchema{
+			Type:   jsonSchemaTypeForKind(unmarshalKind),
+			Format: getJSONSchemaFormat(typ),
+		}
+	}
+
+	if typ == timeType {
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return b.structSchema(typ)
+		}
+		return &JSONSchema{Ref: b.refPrefix + escapeJSONPointer(b.define(typ))}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			// encoding/json encodes []byte as a base64 string
+			return &JSONSchema{Type: "string", ContentEncoding: "base64"}
+		}
+		schema := &JSONSchema{Type: "array", Items: b.schemaFor(typ.Elem())}
+		if typ.Kind() == reflect.Array {
+			n := typ.Len()
+			schema.MinItems = &n
+			schema.MaxItems = &n
+		}
+		return schema
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schemaFor(typ.Elem())}
+	case reflect.Interface:
+		return &JSONSchema{}
+	default:
+		return &JSONSchema{Type: jsonSchemaTypeForKind(typ.Kind().String())}
+	}
+}
+
+// define adds the schema of a named struct type to defs and returns its name.
+func (b *schemaBuilder) define(typ reflect.Type) string {
+	if name, ok := b.names[typ]; ok {
+		return name
+	}
+
+	name := b.defName(typ)
+	b.names[typ] = name
+	// Reserve the name before building the fields, so recursive types reference it.
+	b.defs[name] = &JSONSchema{}
+	*b.defs[name] = *b.structSchema(typ)
+	return name
+}
+
+// defName returns a short name for typ ("pkg.Type"), falling back to the full package path
+// if another type already uses the short name.
+func (b *schemaBuilder) defName(typ reflect.Type) string {
+	if b.qualified {
+		return getQualifiedTypeName(typ)
+	}
+
+	name := typ.Name()
+	if typ.PkgPath() != "" {
+		name = path.Base(typ.PkgPath()) + "." + name
+	}
+	if _, taken := b.defs[name]; !taken {
+		return name
+	}
+	return sanitizeDefName(getQualifiedTypeName(typ))
+}
+
+// escapeJSONPointer escapes a def name to be used as a JSON Pointer reference token (RFC 6901).
+func escapeJSONPointer(name string) string {
+	name = strings.ReplaceAll(name, "~", "~0")
+	return strings.ReplaceAll(name, "/", "~1")
+}
+
+func sanitizeDefName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func (b *schemaBuilder) structSchema(typ reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:       "object",
+		Properties: make(map[string]*JSONSchema),
+	}
+
+	for _, f := range jsonFields(typ) {
+		field, name := f.field, f.name
+
+		// Validation rules apply to the pointed value, so build the nullable schema afterwards.
+		fieldType := stripPointers(field.Type)
+		fieldSchema := b.schemaFor(fieldType)
+
+		validateTag := field.Tag.Get("validate")
+		if validateTag != "" {
+			rules := strings.Split(validateTag, ",")
+			if isRequiredRule(rules) {
+				schema.Required = append(schema.Required, name)
+			}
+			fieldSchema = applyValidationRules(fieldSchema, fieldType, rules)
+		}
+
+		if field.Type.Kind() == reflect.Ptr {
+			fieldSchema = nullableSchema(fieldSchema)
+		}
+
+		fieldSchema.Description = field.Tag.Get("desc")
+		fieldSchema.Default = fieldDefaultJSON(field)
+
+		schema.Properties[name] = fieldSchema
+	}
+
+	return schema
+}
+
+// isRequiredRule reports whether rules contain the unconditional "required" rule.
+// Conditional rules such as required_if or required_with do not make a field always required.
+func isRequiredRule(rules []string) bool {
+	for _, rule := range rules {
+		switch strings.TrimSpace(rule) {
+		case "dive":
+			return false
+		case "required":
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidationRules maps validator tags to JSON Schema keywords.
+// Rules after "dive" apply to the elements of arrays and the values of maps.
+// Unknown rules are ignored.
+func applyValidationRules(schema *JSONSchema, typ reflect.Type, rules []string) *JSONSchema {
+	if schema.Ref != "" {
+		// Keywords next to $ref are ignored by older drafts, so leave references untouched.
+		return schema
+	}
+
+	for i, rule := range rules {
+		rule = strings.TrimSpace(rule)
+		name, value, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			elemType := stripPointers(typ.Elem())
+			switch {
+			case schema.Items != nil:
+				schema.Items = applyElementValidationRules(schema.Items, elemType, rules[i+1:])
+			case schema.AdditionalProperties != nil:
+				schema.AdditionalProperties = applyElementValidationRules(schema.AdditionalProperties, elemType, rules[i+1:])
+			}
+			return schema
+		case "min", "gte":
+			setLowerBound(schema, value, false)
+		case "max", "lte":
+			setUpperBound(schema, value, false)
+		case "gt":
+			setLowerBound(schema, value, true)
+		case "lt":
+			setUpperBound(schema, value, true)
+		case "len":
+			setLowerBound(schema, value, false)
+			setUpperBound(schema, value, false)
+		case "eq":
+			schema.Const = parseRuleValue(schema, value)
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, parseRuleValue(schema, v))
+			}
+		case "unique":
+			if schema.Type == "array" {
+				schema.UniqueItems = true
+			}
+		case "email":
+			schema.Format = "email"
+		case "url", "uri", "http_url":
+			schema.Format = "uri"
+		case "hostname", "hostname_rfc1123":
+			schema.Format = "hostname"
+		case "ipv4", "ip4_addr":
+			schema.Format = "ipv4"
+		case "ipv6", "ip6_addr":
+			schema.Format = "ipv6"
+		case "uuid", "uuid3", "uuid4", "uuid5":
+			schema.Format = "uuid"
+		case "datetime":
+			schema.Format = "date-time"
+		case "alpha":
+			schema.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			schema.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			schema.Pattern = "^[-+]?[0-9]+(?:\\.[0-9]+)?$"
+		case "number":
+			schema.Pattern = "^[0-9]+$"
+		case "hexadecimal":
+			schema.Pattern = "^(0[xX])?[0-9a-fA-F]+$"
+		case "lowercase":
+			schema.Pattern = "^[^A-Z]*$"
+		case "uppercase":
+			schema.Pattern = "^[^a-z]*$"
+		}
+	}
+	return schema
+}
+
+// applyElementValidationRules applies the rules following "dive" to the schema of an element,
+// which is nullable if the elements are pointers.
+func applyElementValidationRules(schema *JSONSchema, typ reflect.Type, rules []string) *JSONSchema {
+	if len(schema.AnyOf) > 0 {
+		return nullableSchema(applyValidationRules(copySchema(schema.AnyOf[0]), typ, rules))
+	}
+	return applyValidationRules(copySchema(schema), typ, rules)
+}
+
+// setLowerBound applies a min/gt rule, whose meaning depends on the schema type.
+func setLowerBound(schema *JSONSchema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if exclusive {
+			schema.ExclusiveMinimum = &n
+		} else {
+			schema.Minimum = &n
+		}
+		return
+	}
+
+	count := int(n)
+	if exclusive {
+		count++
+	}
+	switch schema.Type {
+	case "string":
+		schema.MinLength = &count
+	case "array":
+		schema.MinItems = &count
+	case "object":
+		schema.MinProperties = &count
+	}
+}
+
+// setUpperBound applies a max/lt rule, whose meaning depends on the schema type.
+func setUpperBound(schema *JSONSchema, value string, exclusive bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if exclusive {
+			schema.ExclusiveMaximum = &n
+		} else {
+			schema.Maximum = &n
+		}
+		return
+	}
+
+	count := int(n)
+	if exclusive {
+		count--
+	}
+	switch schema.Type {
+	case "string":
+		schema.MaxLength = &count
+	case "array":
+		schema.MaxItems = &count
+	case "object":
+		schema.MaxProperties = &count
+	}
+}
+
+func parseRuleValue(schema *JSONSchema, value string) interface{} {
+	switch schema.Type {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// nullableSchema allows null in addition to the values accepted by schema.
+func nullableSchema(schema *JSONSchema) *JSONSchema {
+	if len(schema.AnyOf) > 0 {
+		// Already nullable (pointer to pointer)
+		return schema
+	}
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{schema, {Type: "null"}},
+	}
+}
+
+// getJSONSchemaFormat returns the format of types implementing JSONSchemaFormat.
+func getJSONSchemaFormat(typ reflect.Type) string {
+	if typ.Implements(jsonSchemaFormatType) {
+		return reflect.Zero(typ).Interface().(JSONSchemaFormat).JSONSchemaFormat()
+	}
+	if reflect.PointerTo(typ).Implements(jsonSchemaFormatType) {
+		return reflect.New(typ).Interface().(JSONSchemaFormat).JSONSchemaFormat()
+	}
+	return ""
+}
+
+func copySchema(schema *JSONSchema) *JSONSchema {
+	c := *schema
+	return &c
+}
+
+// jsonSchemaTypeForKind maps a reflect kind name (or UnmarshalKind value) to a JSON Schema type.
+func jsonSchemaTypeForKind(kind string) string {
+	switch kind {
+	case "bool", "boolean":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "integer":
+		return "integer"
+	case "float32", "float64", "number":
+		return "number"
+	case "string":
+		return "string"
+	case "slice", "array":
+		return "array"
+	case "struct", "map", "object":
+		return "object"
+	default:
+		return ""
+	}
+}
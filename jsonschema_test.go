@@ -0,0 +1,75 @@
+package autorpc
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type embeddedBase struct {
+	ID   int    `json:"id" validate:"required"`
+	Name string `json:"name"`
+}
+
+type embeddingParams struct {
+	embeddedBase
+	Name  string `json:"name"` // shadows embeddedBase.Name
+	Email string `json:"email" validate:"required_with=Name"`
+}
+
+func TestStructSchemaPromotesEmbeddedFields(t *testing.T) {
+	schema := JSONSchemaFor(reflect.TypeOf(embeddingParams{}))
+	def := schema.Defs["autorpc.embeddingParams"]
+	if def == nil {
+		t.Fatalf("missing definition, got %v", schema.Defs)
+	}
+
+	var names []string
+	for name := range def.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"email", "id", "name"}; !slices.Equal(names, want) {
+		t.Errorf("properties = %v, want %v", names, want)
+	}
+	if want := []string{"id"}; !slices.Equal(def.Required, want) {
+		t.Errorf("required = %v, want %v", def.Required, want)
+	}
+}
+
+func TestExtractFieldsPromotesEmbeddedFields(t *testing.T) {
+	fields := extractFields(reflect.TypeOf(embeddingParams{}))
+
+	var got []string
+	for _, field := range fields {
+		name := field.JSONName
+		if field.Required {
+			name += "!"
+		}
+		got = append(got, name)
+	}
+	if want := []string{"id!", "name", "email"}; !slices.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestIsRequiredRule(t *testing.T) {
+	tests := []struct {
+		rules []string
+		want  bool
+	}{
+		{[]string{"required"}, true},
+		{[]string{"min=1", " required"}, true},
+		{[]string{"required_if=A 1"}, false},
+		{[]string{"required_with=A"}, false},
+		{[]string{"required_without=A"}, false},
+		{[]string{"required_unless=A 1"}, false},
+		{[]string{"dive", "required"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isRequiredRule(tt.rules); got != tt.want {
+			t.Errorf("isRequiredRule(%q) = %v, want %v", tt.rules, got, tt.want)
+		}
+	}
+}
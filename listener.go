@@ -0,0 +1,128 @@
+package autorpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// PeerCredentials identifies the process on the other side of a Unix domain socket.
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+type connKey struct{}
+
+type peerCredentialsKey struct{}
+
+func WithConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// ConnFromContext returns the connection the request was received on,
+// or nil if the request did not come from ServeListener.
+func ConnFromContext(ctx context.Context) net.Conn {
+	if conn, ok := ctx.Value(connKey{}).(net.Conn); ok {
+		return conn
+	}
+	return nil
+}
+
+// RemoteAddrFromContext returns the remote address of the connection the request was received on,
+// or nil if the request did not come from ServeListener.
+func RemoteAddrFromContext(ctx context.Context) net.Addr {
+	if conn := ConnFromContext(ctx); conn != nil {
+		return conn.RemoteAddr()
+	}
+	return nil
+}
+
+// PeerCredentialsFromContext returns the credentials of the peer process for requests received
+// on a Unix domain socket by ServeListener. They are only available on Linux.
+func PeerCredentialsFromContext(ctx context.Context) (PeerCredentials, bool) {
+	creds, ok := ctx.Value(peerCredentialsKey{}).(PeerCredentials)
+	return creds, ok
+}
+
+// ServeListener accepts connections on l and serves a newline framed JSON-RPC session
+// on each of them, like ServeStream. It works with TCP and Unix domain socket listeners.
+//
+// The connection is available to middleware and handlers through ConnFromContext and
+// RemoteAddrFromContext, and for Unix sockets the peer process through PeerCredentialsFromContext.
+//
+// ServeListener blocks until l is closed, then closes the active connections,
+// waits for their sessions to end and returns nil. Temporary accept errors, such as running
+// out of file descriptors, are retried after a delay growing up to one second, like
+// net/http.Server.Serve does. Other accept errors also close the connections and are returned.
+//
+// Example:
+//
+//	l, err := net.Listen("unix", "/run/myservice.sock")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	log.Fatal(server.ServeListener(l))
+func (s *Server) ServeListener(l net.Listener) error {
+	return s.ServeListenerWithOptions(l, StreamOptions{})
+}
+
+// ServeListenerWithOptions is like ServeListener, with the framing given in opts.
+func (s *Server) ServeListenerWithOptions(l net.Listener, opts StreamOptions) error {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+		wg    sync.WaitGroup
+	)
+
+	defer func() {
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	var retryDelay time.Duration
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			if temp, ok := err.(interface{ Temporary() bool }); ok && temp.Temporary() {
+				retryDelay = min(max(2*retryDelay, 5*time.Millisecond), time.Second)
+				time.Sleep(retryDelay)
+				continue
+			}
+			return err
+		}
+		retryDelay = 0
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+
+			ctx := WithConn(context.Background(), conn)
+			if creds, ok := peerCredentials(conn); ok {
+				ctx = context.WithValue(ctx, peerCredentialsKey{}, creds)
+			}
+
+			ServeStream(ctx, s, conn, conn, opts)
+		}()
+	}
+}
@@ -0,0 +1,169 @@
+package autorpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type connInfo struct {
+	HasConn    bool   `json:"hasConn"`
+	RemoteAddr string `json:"remoteAddr"`
+	PID        int32  `json:"pid"`
+}
+
+func newListenerTestServer() *Server {
+	server := newTestServer()
+	RegisterMethod(server, "conn", func(ctx context.Context, p EmptyParams) (connInfo, error) {
+		info := connInfo{HasConn: ConnFromContext(ctx) != nil}
+		if addr := RemoteAddrFromContext(ctx); addr != nil {
+			info.RemoteAddr = addr.Network()
+		}
+		if creds, ok := PeerCredentialsFromContext(ctx); ok {
+			info.PID = creds.PID
+		}
+		return info, nil
+	})
+	return server
+}
+
+// serveUnix serves server on a new Unix socket and returns the listener and the result of ServeListener.
+func serveUnix(t *testing.T, server *Server, wrap func(net.Listener) net.Listener) (net.Listener, string, chan error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rpc.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	served := l
+	if wrap != nil {
+		served = wrap(l)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- server.ServeListener(served)
+	}()
+	return l, path, done
+}
+
+func waitServe(t *testing.T, done chan error) {
+	t.Helper()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("ServeListener = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeListener did not return")
+	}
+}
+
+func TestServeListener(t *testing.T) {
+	l, path, done := serveUnix(t, newListenerTestServer(), nil)
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	responses := bufio.NewReader(conn)
+
+	want := `{"jsonrpc":"2.0","result":{"hasConn":true,"remoteAddr":"unix","pid":0},"id":1}`
+	if runtime.GOOS == "linux" {
+		want = `{"jsonrpc":"2.0","result":{"hasConn":true,"remoteAddr":"unix","pid":` + strconv.Itoa(os.Getpid()) + `},"id":1}`
+	}
+	conn.Write([]byte(`{"jsonrpc":"2.0","method":"conn","id":1}` + "\n"))
+	line, err := responses.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertResponses(t, line, want)
+
+	// Closing the listener ends ServeListener and closes the active connection.
+	l.Close()
+	waitServe(t, done)
+	if _, err := responses.ReadByte(); err == nil {
+		t.Error("connection still open after the listener was closed")
+	}
+}
+
+// flakyListener fails its first Accept calls with a temporary error.
+type flakyListener struct {
+	net.Listener
+	failures atomic.Int32
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary accept error" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures.Add(-1) >= 0 {
+		return nil, temporaryError{}
+	}
+	return l.Listener.Accept()
+}
+
+func TestServeListenerTemporaryErrors(t *testing.T) {
+	l, path, done := serveUnix(t, newTestServer(), func(l net.Listener) net.Listener {
+		flaky := &flakyListener{Listener: l}
+		flaky.failures.Store(3)
+		return flaky
+	})
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	conn.Write([]byte(pingRequest + "\n"))
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("no response after temporary accept errors: %v", err)
+	}
+	assertResponses(t, line, pingResponse)
+
+	l.Close()
+	waitServe(t, done)
+}
+
+// failingListener fails Accept with a permanent error.
+type failingListener struct {
+	net.Listener
+}
+
+var errAcceptFailed = errors.New("accept failed")
+
+func (l failingListener) Accept() (net.Conn, error) {
+	return nil, errAcceptFailed
+}
+
+func TestServeListenerAcceptError(t *testing.T) {
+	_, _, done := serveUnix(t, newTestServer(), func(l net.Listener) net.Listener {
+		return failingListener{Listener: l}
+	})
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errAcceptFailed) {
+			t.Errorf("ServeListener = %v, want %v", err, errAcceptFailed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ServeListener did not return")
+	}
+}
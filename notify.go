@@ -0,0 +1,119 @@
+package autorpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrNotificationsNotSupported is returned by Notify when the request was received on a transport
+// that cannot push messages to the client, such as HTTP without Server-Sent Events.
+var ErrNotificationsNotSupported = errors.New("autorpc: transport does not support server-to-client notifications")
+
+// notifier is implemented by transports that can push notifications to the client.
+type notifier interface {
+	notify(method string, params interface{}) error
+}
+
+type notifierKey struct{}
+
+func withNotifier(ctx context.Context, n notifier) context.Context {
+	return context.WithValue(ctx, notifierKey{}, n)
+}
+
+func notifierFromContext(ctx context.Context) notifier {
+	if n, ok := ctx.Value(notifierKey{}).(notifier); ok {
+		return n
+	}
+	return nil
+}
+
+// Notify sends a JSON-RPC notification to the client that sent the request ctx belongs to.
+// It returns ErrNotificationsNotSupported if the request was not received on a persistent transport.
+//
+// Example:
+//
+//	func Buy(ctx context.Context, params BuyParams) (bool, error) {
+//	    autorpc.Notify(ctx, "price.updated", Price{Symbol: params.Symbol, Value: 42})
+//	    return true, nil
+//	}
+func Notify(ctx context.Context, method string, params interface{}) error {
+	n := notifierFromContext(ctx)
+	if n == nil {
+		return ErrNotificationsNotSupported
+	}
+	return n.notify(method, params)
+}
+
+// ProgressMethod is the method of the notifications sent by ReportProgress.
+const ProgressMethod = "$/progress"
+
+// ProgressParams are the params of ProgressMethod notifications.
+type ProgressParams struct {
+	Token interface{} `json:"token"`
+	Value interface{} `json:"value"`
+}
+
+// ReportProgress notifies the client of the progress of a long-running request.
+// The token lets the client match the notification with its request; if it is nil,
+// the id of the current request is used. The value is free-form, such as a percentage
+// or a {done, total} object.
+//
+// Progress is delivered as ProgressMethod notifications on persistent transports, and as
+// Server-Sent Events over HTTP when the request has an "Accept: text/event-stream" header.
+// Otherwise ReportProgress returns ErrNotificationsNotSupported, which handlers can ignore.
+//
+// Example:
+//
+//	for i, row := range rows {
+//	    importRow(row)
+//	    autorpc.ReportProgress(ctx, nil, map[string]int{"done": i + 1, "total": len(rows)})
+//	}
+func ReportProgress(ctx context.Context, token interface{}, value interface{}) error {
+	if token == nil {
+		if req, ok := rpcRequestFromContext(ctx); ok && req.ID != nil {
+			token = req.ID
+		}
+	}
+	return Notify(ctx, ProgressMethod, ProgressParams{Token: token, Value: value})
+}
+
+// responseHooks holds functions to run once the response of a message has been written.
+type responseHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+func (h *responseHooks) add(fn func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks = append(h.hooks, fn)
+}
+
+func (h *responseHooks) run() {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+type responseHooksKey struct{}
+
+func withResponseHooks(ctx context.Context, h *responseHooks) context.Context {
+	return context.WithValue(ctx, responseHooksKey{}, h)
+}
+
+// afterResponse runs fn once the response to the current message has been written,
+// so that notifications triggered by a request never reach the client before its response.
+// If the transport does not support it, fn runs immediately.
+func afterResponse(ctx context.Context, fn func()) {
+	if h, ok := ctx.Value(responseHooksKey{}).(*responseHooks); ok {
+		h.add(fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,250 @@
+package autorpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OpenRPCVersion is the version of the OpenRPC specification the generated documents follow.
+const OpenRPCVersion = "1.3.2"
+
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []OpenRPCMethod   `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenRPCMethod struct {
+	Name           string                     `json:"name"`
+	Description    string                     `json:"description,omitempty"`
+	Tags           []OpenRPCTag               `json:"tags,omitempty"`
+	Deprecated     bool                       `json:"deprecated,omitempty"`
+	ParamStructure string                     `json:"paramStructure,omitempty"` // "by-name", "by-position" or "either"
+	Params         []OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor  `json:"result,omitempty"`
+	Examples       []OpenRPCExamplePairing    `json:"examples,omitempty"`
+	Errors         []OpenRPCError             `json:"errors,omitempty"`
+}
+
+type OpenRPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type OpenRPCContentDescriptor struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+type OpenRPCTag struct {
+	Name string `json:"name"`
+}
+
+type OpenRPCExamplePairing struct {
+	Name   string           `json:"name"`
+	Params []OpenRPCExample `json:"params"`
+	Result *OpenRPCExample  `json:"result,omitempty"`
+}
+
+type OpenRPCExample struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas,omitempty"`
+}
+
+// SetOpenRPCInfo sets the info object of the documents returned by OpenRPCDocument.
+// Title and version default to "autorpc" and "1.0.0".
+func (s *Server) SetOpenRPCInfo(info OpenRPCInfo) {
+	s.openRPCInfo = info
+}
+
+// OpenRPCDocument returns an OpenRPC document describing all registered methods.
+// Named struct types are emitted once in components/schemas and referenced from the methods,
+// and validate tags are mapped to the matching JSON Schema keywords.
+//
+// Struct params are described with one content descriptor per field, and can be sent
+// by-name or by-position. Other params types are described as a single descriptor named "params",
+// sent by-position: a method taking []float32 is called with [[1, 2]].
+func (s *Server) OpenRPCDocument() OpenRPCDocument {
+	info := s.openRPCInfo
+	if info.Title == "" {
+		info.Title = "autorpc"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+
+	builder := newSchemaBuilder("#/components/schemas/", false)
+	methods := []OpenRPCMethod{}
+
+	s.methods.Range(func(key, value interface{}) bool {
+		methodName := key.(string)
+		handler := value.(*methodHandler)
+
+		paramType := handler.paramType
+		resultType := handler.resultType
+
+		method := OpenRPCMethod{
+			Name:        methodName,
+			Description: handler.metadata.description,
+			Deprecated:  handler.metadata.deprecated,
+			Result: &OpenRPCContentDescriptor{
+				Name:   "result",
+				Schema: builder.schemaFor(resultType),
+			},
+		}
+		if handler.metadata.deprecationReason != "" {
+			method.Description = strings.TrimSpace(method.Description + "\n\nDeprecated: " + handler.metadata.deprecationReason)
+		}
+		for _, tag := range handler.metadata.tags {
+			method.Tags = append(method.Tags, OpenRPCTag{Name: tag})
+		}
+		method.ParamStructure, method.Params = openRPCParams(builder, paramType, handler.positionalParams)
+		for _, declared := range handler.metadata.errors {
+			method.Errors = append(method.Errors, OpenRPCError{
+				Code:    declared.Code(),
+				Message: declared.Message(),
+				Data:    declared.Data(),
+			})
+		}
+		for i, example := range handler.metadata.examples {
+			method.Examples = append(method.Examples, openRPCExample(fmt.Sprintf("example%d", i+1), example, method.Params))
+		}
+
+		methods = append(methods, method)
+		return true
+	})
+
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	return OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    info,
+		Methods: methods,
+		Components: OpenRPCComponents{
+			Schemas: builder.defs,
+		},
+	}
+}
+
+// openRPCParams describes the params of a method as content descriptors.
+// Fields accepted by position come first, in positional order.
+func openRPCParams(builder *schemaBuilder, paramType reflect.Type, positional []string) (string, []OpenRPCContentDescriptor) {
+	baseType := stripPointers(paramType)
+	if positional == nil {
+		structure := ""
+		if acceptsWrappedParams(paramType) {
+			structure = "by-position"
+		}
+		return structure, []OpenRPCContentDescriptor{{
+			Name:     "params",
+			Required: true,
+			Schema:   builder.schemaFor(paramType),
+		}}
+	}
+
+	// Describe each field as a param, reusing the struct schema built for the components.
+	var structSchema *JSONSchema
+	if baseType.Name() == "" {
+		structSchema = builder.structSchema(baseType)
+	} else {
+		structSchema = builder.defs[builder.define(baseType)]
+	}
+
+	required := make(map[string]bool, len(structSchema.Required))
+	for _, name := range structSchema.Required {
+		required[name] = true
+	}
+
+	names := append([]string{}, positional...)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, field := range extractFields(baseType) {
+		if !seen[field.JSONName] {
+			names = append(names, field.JSONName)
+		}
+	}
+
+	params := []OpenRPCContentDescriptor{}
+	for _, name := range names {
+		schema, ok := structSchema.Properties[name]
+		if !ok {
+			continue
+		}
+		params = append(params, OpenRPCContentDescriptor{
+			Name:        name,
+			Description: schema.Description,
+			Required:    required[name],
+			Schema:      schema,
+		})
+	}
+
+	structure := "either"
+	if len(positional) < len(params) {
+		// Some fields can only be passed by name
+		structure = "by-name"
+	}
+	return structure, params
+}
+
+// openRPCExample converts an example to an OpenRPC example pairing,
+// splitting struct params into one example per param descriptor.
+func openRPCExample(name string, example MethodExample, descriptors []OpenRPCContentDescriptor) OpenRPCExamplePairing {
+	pairing := OpenRPCExamplePairing{
+		Name:   name,
+		Params: []OpenRPCExample{},
+		Result: &OpenRPCExample{Name: "result", Value: example.Result},
+	}
+
+	if len(descriptors) == 1 && descriptors[0].Name == "params" {
+		pairing.Params = append(pairing.Params, OpenRPCExample{Name: "params", Value: example.Params})
+		return pairing
+	}
+
+	var values map[string]json.RawMessage
+	if data, err := json.Marshal(example.Params); err == nil {
+		json.Unmarshal(data, &values)
+	}
+	for _, descriptor := range descriptors {
+		if value, ok := values[descriptor.Name]; ok {
+			pairing.Params = append(pairing.Params, OpenRPCExample{Name: descriptor.Name, Value: value})
+		}
+	}
+	return pairing
+}
+
+// OpenRPCHandler serves the OpenRPC document of the server as JSON.
+func OpenRPCHandler(server *Server) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		doc := server.OpenRPCDocument()
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(doc)
+	})
+}
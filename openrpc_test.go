@@ -0,0 +1,153 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type openRPCUser struct {
+	ID   int    `json:"id" validate:"required" desc:"User id"`
+	Name string `json:"name,omitempty"`
+}
+
+type openRPCTagged struct {
+	Query string `json:"query" rpc:"pos=0"`
+	Limit int    `json:"limit"`
+}
+
+func openRPCMethod(t *testing.T, doc OpenRPCDocument, name string) OpenRPCMethod {
+	t.Helper()
+	for _, method := range doc.Methods {
+		if method.Name == name {
+			return method
+		}
+	}
+	t.Fatalf("method %q not in document", name)
+	return OpenRPCMethod{}
+}
+
+func descriptorNames(descriptors []OpenRPCContentDescriptor) []string {
+	names := []string{}
+	for _, descriptor := range descriptors {
+		names = append(names, descriptor.Name)
+	}
+	return names
+}
+
+func TestOpenRPCDocument(t *testing.T) {
+	server := NewServer()
+	RegisterMethodWithOptions(server, "users.get", func(ctx context.Context, p openRPCUser) (openRPCUser, error) {
+		return p, nil
+	}, WithDescription("Gets a user"), WithTags("users"), WithExample(openRPCUser{ID: 1}, openRPCUser{ID: 1, Name: "Ann"}))
+	RegisterMethod(server, "search", func(ctx context.Context, p openRPCTagged) ([]string, error) {
+		return nil, nil
+	})
+	RegisterMethodWithOptions(server, "sum", func(ctx context.Context, p []float32) (float32, error) {
+		return 0, nil
+	}, WithExample([]float32{1, 2}, 3))
+	RegisterMethod(server, "echo", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	})
+	RegisterMethod(server, "tuple", func(ctx context.Context, p positionalTuple) (int, error) {
+		return 0, nil
+	})
+
+	doc := server.OpenRPCDocument()
+	if doc.OpenRPC != OpenRPCVersion || doc.Info.Title != "autorpc" || doc.Info.Version != "1.0.0" {
+		t.Errorf("header = %q %+v, want defaults", doc.OpenRPC, doc.Info)
+	}
+	if _, ok := doc.Components.Schemas["autorpc.openRPCUser"]; !ok {
+		t.Errorf("components = %v, want openRPCUser schema", doc.Components.Schemas)
+	}
+
+	tests := []struct {
+		method    string
+		structure string
+		params    []string
+	}{
+		{method: "users.get", structure: "either", params: []string{"id", "name"}},
+		{method: "search", structure: "by-name", params: []string{"query", "limit"}},
+		{method: "sum", structure: "by-position", params: []string{"params"}},
+		{method: "echo", structure: "by-position", params: []string{"params"}},
+		{method: "tuple", structure: "", params: []string{"params"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			method := openRPCMethod(t, doc, tt.method)
+			if method.ParamStructure != tt.structure {
+				t.Errorf("paramStructure = %q, want %q", method.ParamStructure, tt.structure)
+			}
+			if got := descriptorNames(method.Params); !reflect.DeepEqual(got, tt.params) {
+				t.Errorf("params = %v, want %v", got, tt.params)
+			}
+		})
+	}
+
+	t.Run("struct params", func(t *testing.T) {
+		method := openRPCMethod(t, doc, "users.get")
+		if method.Description != "Gets a user" || len(method.Tags) != 1 || method.Tags[0].Name != "users" {
+			t.Errorf("metadata = %q %v", method.Description, method.Tags)
+		}
+		id := method.Params[0]
+		if !id.Required || id.Description != "User id" || id.Schema.Type != "integer" {
+			t.Errorf("id = %+v, want required integer with description", id)
+		}
+		if method.Params[1].Required {
+			t.Errorf("name is required, want optional")
+		}
+		if method.Result.Schema.Ref != "#/components/schemas/autorpc.openRPCUser" {
+			t.Errorf("result schema = %+v, want component reference", method.Result.Schema)
+		}
+	})
+
+	t.Run("non-struct params", func(t *testing.T) {
+		method := openRPCMethod(t, doc, "sum")
+		schema := method.Params[0].Schema
+		if !method.Params[0].Required || schema.Type != "array" || schema.Items == nil || schema.Items.Type != "number" {
+			t.Errorf("params = %+v, want required array of numbers", method.Params[0])
+		}
+	})
+}
+
+func TestOpenRPCExamples(t *testing.T) {
+	server := NewServer()
+	RegisterMethodWithOptions(server, "users.get", func(ctx context.Context, p openRPCUser) (openRPCUser, error) {
+		return p, nil
+	}, WithExample(openRPCUser{ID: 1}, openRPCUser{ID: 1, Name: "Ann"}))
+	RegisterMethodWithOptions(server, "sum", func(ctx context.Context, p []float32) (float32, error) {
+		return 0, nil
+	}, WithExample([]float32{1, 2}, 3))
+
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{
+			method: "users.get",
+			// name is omitted from the params since it is empty
+			want: `[{"name":"example1","params":[{"name":"id","value":1}],"result":{"name":"result","value":{"id":1,"name":"Ann"}}}]`,
+		},
+		{
+			method: "sum",
+			want:   `[{"name":"example1","params":[{"name":"params","value":[1,2]}],"result":{"name":"result","value":3}}]`,
+		},
+	}
+
+	doc := server.OpenRPCDocument()
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			got, err := json.Marshal(openRPCMethod(t, doc, tt.method).Examples)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var g, w interface{}
+			json.Unmarshal(got, &g)
+			json.Unmarshal([]byte(tt.want), &w)
+			if !reflect.DeepEqual(g, w) {
+				t.Errorf("examples = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
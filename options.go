@@ -0,0 +1,143 @@
+package autorpc
+
+import (
+	"fmt"
+	"time"
+)
+
+// MethodOption configures a method registered with RegisterMethodWithOptions.
+// Middleware values are method options too, so they can be mixed with the With* options:
+//
+//	autorpc.RegisterMethodWithOptions(server, "users.get", GetUser,
+//	    autorpc.WithDescription("Returns a user by id"),
+//	    autorpc.WithTags("users"),
+//	    AuthMiddleware(),
+//	)
+type MethodOption interface {
+	applyMethodOption(*methodOptions)
+}
+
+// MethodExample is an example call of a method, shown in the spec.
+type MethodExample struct {
+	Params interface{} `json:"params"`
+	Result interface{} `json:"result"`
+}
+
+// methodOptions holds the configuration of a method collected from its MethodOptions.
+type methodOptions struct {
+	middlewares  *MiddlewareChain
+	metadata     methodMetadata
+	strictParams *bool // overrides Server.SetStrictParams when set
+	useNumber    *bool // overrides Server.SetUseNumber when set
+	timeout      *time.Duration
+	reserved     bool // allows a name reserved by the JSON-RPC specification, for built-in methods
+}
+
+// methodMetadata is the documentation of a method, surfaced by GetMethodSpecs.
+type methodMetadata struct {
+	description       string
+	tags              []string
+	examples          []MethodExample
+	deprecated        bool
+	deprecationReason string
+	errors            []RPCErrorProvider
+	streaming         bool // registered with RegisterStream
+}
+
+func newMethodOptions(opts ...MethodOption) *methodOptions {
+	options := &methodOptions{
+		middlewares: NewMiddlewareChain(),
+	}
+	for _, opt := range opts {
+		opt.applyMethodOption(options)
+	}
+	return options
+}
+
+func (m Middleware) applyMethodOption(o *methodOptions) {
+	o.middlewares.Add(m)
+}
+
+// WithMiddleware adds method-specific middleware. Middleware values can also be passed as
+// method options directly; WithMiddleware forwards an existing []Middleware:
+//
+//	autorpc.RegisterMethodWithOptions(server, "users.get", GetUser, autorpc.WithMiddleware(mws...))
+func WithMiddleware(mws ...Middleware) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		for _, mw := range mws {
+			o.middlewares.Add(mw)
+		}
+	})
+}
+
+type methodOptionFunc func(*methodOptions)
+
+func (f methodOptionFunc) applyMethodOption(o *methodOptions) {
+	f(o)
+}
+
+// WithDescription sets the human-readable description of the method.
+func WithDescription(description string) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.metadata.description = description
+	})
+}
+
+// WithTags adds tags to the method, used to group methods in documentation.
+func WithTags(tags ...string) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.metadata.tags = append(o.metadata.tags, tags...)
+	})
+}
+
+// WithExample adds an example call of the method. It can be used several times.
+func WithExample(params, result interface{}) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.metadata.examples = append(o.metadata.examples, MethodExample{Params: params, Result: result})
+	})
+}
+
+// WithErrors declares the errors the method can return. Each error must implement RPCErrorProvider;
+// its code, message and the type of its data are published in the spec.
+//
+// Example:
+//
+//	var ErrNotFound = autorpc.NewError(-32004, "User not found", nil)
+//
+//	autorpc.RegisterMethodWithOptions(server, "users.get", GetUser, autorpc.WithErrors(ErrNotFound))
+func WithErrors(errs ...error) MethodOption {
+	providers := make([]RPCErrorProvider, 0, len(errs))
+	for _, err := range errs {
+		provider, ok := err.(RPCErrorProvider)
+		if !ok {
+			panic(fmt.Sprintf("WithErrors: error %q does not implement RPCErrorProvider", err))
+		}
+		providers = append(providers, provider)
+	}
+
+	return methodOptionFunc(func(o *methodOptions) {
+		o.metadata.errors = append(o.metadata.errors, providers...)
+	})
+}
+
+// WithDeprecated marks the method as deprecated. The reason should tell clients what to use instead.
+func WithDeprecated(reason string) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.metadata.deprecated = true
+		o.metadata.deprecationReason = reason
+	})
+}
+
+// WithStrictParams overrides the server StrictParams setting for the method (see Server.SetStrictParams).
+func WithStrictParams(strict bool) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.strictParams = &strict
+	})
+}
+
+// WithUseNumber overrides the server UseNumber setting for the method (see Server.SetUseNumber).
+func WithUseNumber(useNumber bool) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.useNumber = &useNumber
+	})
+}
@@ -0,0 +1,78 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestWithMiddleware(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(ctx context.Context, req RPCRequest, next HandlerFunc) (RPCResponse, error) {
+			calls = append(calls, name)
+			return next(ctx, req)
+		}
+	}
+	mws := []Middleware{record("a"), record("b")}
+
+	server := NewServer()
+	RegisterMethodWithOptions(server, "echo", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}, WithDescription("Echoes"), WithMiddleware(mws...), record("c"))
+
+	resp := server.processRequest(context.Background(), RPCRequest{
+		JSONRPC: "2.0",
+		Method:  "echo",
+		Params:  json.RawMessage(`"hi"`),
+		ID:      json.RawMessage(`1`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(calls, want) {
+		t.Errorf("middleware calls = %v, want %v", calls, want)
+	}
+}
+
+func plainMiddleware(ctx context.Context, req RPCRequest, next HandlerFunc) (RPCResponse, error) {
+	resp, err := next(ctx, req)
+	resp.Result = "plain:" + resp.Result.(string)
+	return resp, err
+}
+
+// RegisterMethod keeps accepting middleware functions and literals that are not typed as Middleware.
+func TestRegisterMethodMiddlewareFuncs(t *testing.T) {
+	server := NewServer()
+	mws := []Middleware{plainMiddleware}
+	RegisterMethod(server, "echo", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}, plainMiddleware, func(ctx context.Context, req RPCRequest, next HandlerFunc) (RPCResponse, error) {
+		resp, err := next(ctx, req)
+		resp.Result = "literal:" + resp.Result.(string)
+		return resp, err
+	})
+	RegisterMethod(server, "echo2", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	}, mws...)
+
+	tests := []struct {
+		method string
+		want   string
+	}{
+		{"echo", "plain:literal:hi"},
+		{"echo2", "plain:hi"},
+	}
+	for _, tt := range tests {
+		resp := server.processRequest(context.Background(), RPCRequest{
+			JSONRPC: "2.0",
+			Method:  tt.method,
+			Params:  json.RawMessage(`"hi"`),
+			ID:      json.RawMessage(`1`),
+		})
+		if resp.Error != nil || resp.Result != tt.want {
+			t.Errorf("%s = %+v, want %q", tt.method, resp, tt.want)
+		}
+	}
+}
@@ -0,0 +1,403 @@
+package autorpc
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonFieldName returns the name of a struct field in JSON, and false if the field is not encoded.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	jsonTag := field.Tag.Get("json")
+	if jsonTag == "-" {
+		return "", false
+	}
+	if name, _, _ := strings.Cut(jsonTag, ","); name != "" {
+		return name, true
+	}
+	return field.Name, true
+}
+
+// jsonField is a field of a struct as encoded by encoding/json.
+type jsonField struct {
+	name   string
+	field  reflect.StructField
+	index  []int // index of the field, for reflect.Type.FieldByIndex
+	tagged bool  // the name comes from a json tag
+}
+
+// jsonFields returns the fields of a struct type as encoding/json encodes them, in order.
+// Fields of embedded structs without a JSON name are promoted. Among fields with the same name,
+// the least nested one wins; at equal depth a tagged field wins, and otherwise all are dropped.
+func jsonFields(typ reflect.Type) []jsonField {
+	var all []jsonField
+	collectJSONFields(typ, nil, map[reflect.Type]bool{typ: true}, &all)
+
+	byName := make(map[string][]jsonField, len(all))
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fields := make([]jsonField, 0, len(all))
+	for _, f := range all {
+		if dominant, ok := dominantJSONField(byName[f.name]); ok && sameIndex(dominant.index, f.index) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func collectJSONFields(typ reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]jsonField) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		jsonTag := field.Tag.Get("json")
+		if jsonTag == "-" {
+			continue
+		}
+		tagName, _, _ := strings.Cut(jsonTag, ",")
+		fieldIndex := append(append([]int(nil), index...), i)
+
+		if field.Anonymous {
+			embedded := stripPointers(field.Type)
+			if tagName == "" && embedded.Kind() == reflect.Struct {
+				if !visited[embedded] {
+					visited[embedded] = true
+					collectJSONFields(embedded, fieldIndex, visited, fields)
+					delete(visited, embedded)
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := tagName
+		if name == "" {
+			name = field.Name
+		}
+		*fields = append(*fields, jsonField{name: name, field: field, index: fieldIndex, tagged: tagName != ""})
+	}
+}
+
+// dominantJSONField returns the field encoding/json keeps among fields with the same name.
+func dominantJSONField(fields []jsonField) (jsonField, bool) {
+	depth := len(fields[0].index)
+	for _, f := range fields[1:] {
+		depth = min(depth, len(f.index))
+	}
+
+	var candidates, tagged []jsonField
+	for _, f := range fields {
+		if len(f.index) == depth {
+			candidates = append(candidates, f)
+			if f.tagged {
+				tagged = append(tagged, f)
+			}
+		}
+	}
+	if len(tagged) == 1 {
+		return tagged[0], true
+	}
+	if len(tagged) == 0 && len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return jsonField{}, false
+}
+
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// rpcTagPosition returns the position given by an `rpc:"pos=N"` tag.
+func rpcTagPosition(typ reflect.Type, field reflect.StructField) (int, bool, error) {
+	for _, option := range strings.Split(field.Tag.Get("rpc"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		if key != "pos" {
+			continue
+		}
+		pos, err := strconv.Atoi(value)
+		if err != nil || pos < 0 {
+			return 0, false, fmt.Errorf("invalid rpc tag %q for field %s.%s: position must be a non-negative integer", field.Tag.Get("rpc"), typ, field.Name)
+		}
+		return pos, true, nil
+	}
+	return 0, false, nil
+}
+
+// positionalParams returns the JSON names of the fields of a struct params type in positional order,
+// or nil if the type does not accept positional params. Fields of embedded structs are promoted.
+//
+// If any field has an `rpc:"pos=N"` tag, only tagged fields can be passed by position, ordered by N.
+// Positions must be unique and start at 0 without gaps. Otherwise all fields are, in declaration order.
+// Types with their own JSON decoding receive arrays unchanged.
+func positionalParams(paramType reflect.Type) ([]string, error) {
+	typ := stripPointers(paramType)
+	if typ.Kind() != reflect.Struct || getUnmarshalKind(typ) != "" || typ == timeType || decodesItself(typ) {
+		return nil, nil
+	}
+
+	type positional struct {
+		name  string
+		field string
+		pos   int
+	}
+	var all, tagged []positional
+
+	for _, f := range jsonFields(typ) {
+		all = append(all, positional{name: f.name, pos: len(all)})
+		pos, ok, err := rpcTagPosition(typ, f.field)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			tagged = append(tagged, positional{name: f.name, field: f.field.Name, pos: pos})
+		}
+	}
+
+	fields := all
+	if len(tagged) > 0 {
+		sort.SliceStable(tagged, func(i, j int) bool {
+			return tagged[i].pos < tagged[j].pos
+		})
+		for i, field := range tagged {
+			switch {
+			case i > 0 && field.pos == tagged[i-1].pos:
+				return nil, fmt.Errorf("invalid rpc tags of %s: fields %s and %s have the same position %d", typ, tagged[i-1].field, field.field, field.pos)
+			case field.pos != i:
+				return nil, fmt.Errorf("invalid rpc tags of %s: position %d is missing", typ, i)
+			}
+		}
+		fields = tagged
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, field.name)
+	}
+	return names, nil
+}
+
+// positionalToNamedParams converts params sent as an array into an object keyed by the names
+// returned by positionalParams. Params that are not an array, or of a type that does not accept
+// positional params (nil names), are returned unchanged. A struct without positional fields
+// still accepts an empty array.
+func positionalToNamedParams(params json.RawMessage, names []string) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(params)
+	if names == nil || len(trimmed) == 0 || trimmed[0] != '[' {
+		return params, nil
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(trimmed, &values); err != nil {
+		return nil, err
+	}
+	if len(values) > len(names) {
+		return nil, fmt.Errorf("too many positional params: got %d, expected at most %d", len(values), len(names))
+	}
+
+	object := make(map[string]json.RawMessage, len(values))
+	for i, value := range values {
+		object[names[i]] = value
+	}
+	return json.Marshal(object)
+}
+
+// acceptsWrappedParams reports whether params of type paramType can also be sent by position,
+// as an array holding the value as its only element. This is the case for types that are
+// neither structs nor decode JSON themselves, such as slices, maps and scalars.
+func acceptsWrappedParams(paramType reflect.Type) bool {
+	typ := stripPointers(paramType)
+	return typ.Kind() != reflect.Struct && !decodesItself(typ)
+}
+
+// unwrapParams returns the only element of params sent as a one-element array.
+// The caller decodes params as they are first, so a method taking []float32 accepts
+// both [1, 2] and [[1, 2]].
+func unwrapParams(params json.RawMessage) (json.RawMessage, bool) {
+	trimmed := bytes.TrimSpace(params)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		return nil, false
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(trimmed, &values); err != nil || len(values) != 1 {
+		return nil, false
+	}
+	return values[0], true
+}
+
+// decodeOptions controls how params are decoded.
+type decodeOptions struct {
+	strict    bool // reject unknown fields and trailing data
+	useNumber bool // decode numbers in interface{} values as json.Number
+}
+
+// unknownFieldError is returned in strict mode when params contain a field that is not in the params type.
+type unknownFieldError struct {
+	path string
+}
+
+func (e *unknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.path)
+}
+
+// decodeParams decodes params into v, a pointer to the params type of a method.
+// Params can only be omitted for methods taking EmptyParams.
+func decodeParams(params json.RawMessage, v interface{}, opts decodeOptions) error {
+	if len(bytes.TrimSpace(params)) == 0 && reflect.TypeOf(v).Elem() == emptyParamsType {
+		return nil
+	}
+
+	if !opts.strict && !opts.useNumber {
+		return json.Unmarshal(params, v)
+	}
+
+	// encoding/json matches keys case-insensitively, so "userid" would silently fill UserID.
+	if opts.strict {
+		if path := unknownFieldPath(params, reflect.TypeOf(v)); path != "" {
+			return &unknownFieldError{path: path}
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(params))
+	if opts.strict {
+		dec.DisallowUnknownFields()
+	}
+	if opts.useNumber {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("unexpected data after params")
+	}
+	return nil
+}
+
+// paramsErrorPath returns the path of the value of params that caused the decoding error err,
+// or "" if it is not known.
+func paramsErrorPath(err error) string {
+	var fieldErr *unknownFieldError
+	if errors.As(err, &fieldErr) {
+		return fieldErr.path
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return typeErr.Field
+	}
+	return ""
+}
+
+// unknownFieldPath returns the path of the first object key of data that does not match
+// a field of typ, such as "items[0].userid", or "" if every key matches.
+func unknownFieldPath(data json.RawMessage, typ reflect.Type) string {
+	typ = stripPointers(typ)
+	if decodesItself(typ) {
+		return ""
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return ""
+		}
+		fields := knownJSONFields(typ)
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fieldType, ok := fields[key]
+			if !ok {
+				return key
+			}
+			if path := unknownFieldPath(object[key], fieldType); path != "" {
+				return joinFieldPath(key, path)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return ""
+		}
+		for i, item := range items {
+			if path := unknownFieldPath(item, typ.Elem()); path != "" {
+				return joinFieldPath("["+strconv.Itoa(i)+"]", path)
+			}
+		}
+	case reflect.Map:
+		var object map[string]json.RawMessage
+		if json.Unmarshal(data, &object) != nil {
+			return ""
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			if path := unknownFieldPath(object[key], typ.Elem()); path != "" {
+				return joinFieldPath(key, path)
+			}
+		}
+	}
+	return ""
+}
+
+// joinFieldPath appends path to the path prefix, separating field names with dots.
+func joinFieldPath(prefix, path string) string {
+	if path[0] == '[' {
+		return prefix + path
+	}
+	return prefix + "." + path
+}
+
+// knownJSONFields returns the types of the JSON fields of a struct keyed by their name,
+// including the fields promoted from embedded structs.
+func knownJSONFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for _, f := range jsonFields(typ) {
+		fields[f.name] = f.field.Type
+	}
+	return fields
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodesItself reports whether values of typ are decoded by their own UnmarshalJSON or UnmarshalText.
+func decodesItself(typ reflect.Type) bool {
+	ptr := reflect.PointerTo(typ)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
@@ -0,0 +1,249 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type positionalBase struct {
+	A int `json:"a"`
+}
+
+type positionalEmbedding struct {
+	positionalBase
+	B int `json:"b"`
+}
+
+type positionalTagged struct {
+	positionalBase
+	B int `json:"b" rpc:"pos=0"`
+	C int `json:"c" rpc:"pos=1"`
+}
+
+type positionalInvalidTag struct {
+	A int `json:"a" rpc:"pos=first"`
+}
+
+type positionalDuplicateTag struct {
+	A int `json:"a" rpc:"pos=0"`
+	B int `json:"b" rpc:"pos=1"`
+	C int `json:"c" rpc:"pos=1"`
+}
+
+type positionalGapTag struct {
+	A int `json:"a" rpc:"pos=0"`
+	B int `json:"b" rpc:"pos=2"`
+}
+
+type positionalStartTag struct {
+	A int `json:"a" rpc:"pos=1"`
+}
+
+// positionalTuple decodes itself from a [a, b] array.
+type positionalTuple struct {
+	A, B int
+}
+
+func (t *positionalTuple) UnmarshalJSON(data []byte) error {
+	var values [2]int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	t.A, t.B = values[0], values[1]
+	return nil
+}
+
+func TestPositionalParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     reflect.Type
+		want    []string
+		wantErr string
+	}{
+		{name: "embedded", typ: reflect.TypeOf(positionalEmbedding{}), want: []string{"a", "b"}},
+		{name: "tagged", typ: reflect.TypeOf(positionalTagged{}), want: []string{"b", "c"}},
+		{name: "pointer", typ: reflect.TypeOf(&positionalEmbedding{}), want: []string{"a", "b"}},
+		{name: "scalar", typ: reflect.TypeOf(0), want: nil},
+		{name: "custom decoder", typ: reflect.TypeOf(positionalTuple{}), want: nil},
+		{name: "invalid tag", typ: reflect.TypeOf(positionalInvalidTag{}), wantErr: `invalid rpc tag "pos=first"`},
+		{name: "duplicate position", typ: reflect.TypeOf(positionalDuplicateTag{}), wantErr: "fields B and C have the same position 1"},
+		{name: "gap", typ: reflect.TypeOf(positionalGapTag{}), wantErr: "position 1 is missing"},
+		{name: "not starting at 0", typ: reflect.TypeOf(positionalStartTag{}), wantErr: "position 0 is missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := positionalParams(tt.typ)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("positionalParams = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPositionalParamsEmbeddedDecode(t *testing.T) {
+	server := NewServer()
+	RegisterMethod(server, "sum", func(ctx context.Context, p positionalEmbedding) (positionalEmbedding, error) {
+		return p, nil
+	})
+
+	resp := server.processRequest(context.Background(), RPCRequest{
+		JSONRPC: "2.0",
+		Method:  "sum",
+		Params:  json.RawMessage(`[1, 2]`),
+		ID:      json.RawMessage(`1`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	if got := resp.Result.(positionalEmbedding); got.A != 1 || got.B != 2 {
+		t.Errorf("params = %+v, want {A:1 B:2}", got)
+	}
+}
+
+func TestPositionalParamsCustomDecoder(t *testing.T) {
+	server := NewServer()
+	RegisterMethod(server, "sum", func(ctx context.Context, p positionalTuple) (int, error) {
+		return p.A + p.B, nil
+	})
+
+	resp := server.processRequest(context.Background(), RPCRequest{
+		JSONRPC: "2.0",
+		Method:  "sum",
+		Params:  json.RawMessage(`[1, 2]`),
+		ID:      json.RawMessage(`1`),
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %+v", resp.Error)
+	}
+	if resp.Result != 3 {
+		t.Errorf("result = %v, want 3", resp.Result)
+	}
+}
+
+func TestRegisterInvalidPositions(t *testing.T) {
+	for _, fn := range []interface{}{
+		func(ctx context.Context, p positionalDuplicateTag) (int, error) { return 0, nil },
+		func(ctx context.Context, p positionalGapTag) (int, error) { return 0, nil },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("register %T did not panic", fn)
+				}
+			}()
+			NewServer().register("m", fn, newMethodOptions())
+		}()
+	}
+}
+
+type invalidTagService struct{}
+
+func (invalidTagService) Get(ctx context.Context, p positionalInvalidTag) (int, error) {
+	return p.A, nil
+}
+
+type gapTagService struct{}
+
+func (gapTagService) Get(ctx context.Context, p positionalGapTag) (int, error) {
+	return p.A, nil
+}
+
+type duplicateTagService struct{}
+
+func (duplicateTagService) Get(ctx context.Context, p positionalDuplicateTag) (int, error) {
+	return p.A, nil
+}
+
+func TestRegisterServiceInvalidRPCTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		svc     any
+		wantErr string
+	}{
+		{name: "invalid", svc: invalidTagService{}, wantErr: "invalid rpc tag"},
+		{name: "gap", svc: gapTagService{}, wantErr: "position 1 is missing"},
+		{name: "duplicate", svc: duplicateTagService{}, wantErr: "same position 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer()
+			err := RegisterService(server, "svc.", tt.svc)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("RegisterService error = %v, want %q", err, tt.wantErr)
+			}
+			if _, ok := server.methods.Load("svc.get"); ok {
+				t.Error("method registered despite the error")
+			}
+		})
+	}
+}
+
+func TestWrappedParams(t *testing.T) {
+	server := NewServer()
+	RegisterMethod(server, "sum", func(ctx context.Context, p []float32) (float32, error) {
+		var sum float32
+		for _, v := range p {
+			sum += v
+		}
+		return sum, nil
+	})
+	RegisterMethod(server, "count", func(ctx context.Context, p [][]int) (int, error) {
+		return len(p), nil
+	})
+	RegisterMethod(server, "tuple", func(ctx context.Context, p positionalTuple) (int, error) {
+		return p.A + p.B, nil
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		params  string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "plain slice", method: "sum", params: `[1, 2]`, want: float32(3)},
+		{name: "wrapped slice", method: "sum", params: `[[1, 2]]`, want: float32(3)},
+		{name: "wrapped empty slice", method: "sum", params: `[[]]`, want: float32(0)},
+		{name: "two wrapped values", method: "sum", params: `[[1], [2]]`, wantErr: true},
+		{name: "wrapped wrong type", method: "sum", params: `[["a"]]`, wantErr: true},
+		// An array that decodes as the params type is never unwrapped.
+		{name: "nested slice", method: "count", params: `[[1, 2]]`, want: 1},
+		{name: "custom decoder", method: "tuple", params: `[[1, 2]]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := server.processRequest(context.Background(), RPCRequest{
+				JSONRPC: "2.0",
+				Method:  tt.method,
+				Params:  json.RawMessage(tt.params),
+				ID:      json.RawMessage(`1`),
+			})
+			if tt.wantErr {
+				if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+					t.Fatalf("response = %+v, want invalid params error", resp)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %+v", resp.Error)
+			}
+			if resp.Result != tt.want {
+				t.Errorf("result = %v, want %v", resp.Result, tt.want)
+			}
+		})
+	}
+}
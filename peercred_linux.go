@@ -0,0 +1,36 @@
+//go:build linux
+
+package autorpc
+
+import (
+	"net"
+	"syscall"
+)
+
+// peerCredentials reads the credentials of the peer of a Unix domain socket with SO_PEERCRED.
+func peerCredentials(conn net.Conn) (PeerCredentials, bool) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return PeerCredentials{}, false
+	}
+
+	rawConn, err := unixConn.SyscallConn()
+	if err != nil {
+		return PeerCredentials{}, false
+	}
+
+	var ucred *syscall.Ucred
+	var credErr error
+	err = rawConn.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return PeerCredentials{}, false
+	}
+
+	return PeerCredentials{
+		PID: ucred.Pid,
+		UID: ucred.Uid,
+		GID: ucred.Gid,
+	}, true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package autorpc
+
+import "net"
+
+// peerCredentials is only implemented on Linux.
+func peerCredentials(conn net.Conn) (PeerCredentials, bool) {
+	return PeerCredentials{}, false
+}
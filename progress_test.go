@@ -0,0 +1,184 @@
+package autorpc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// readEvents returns the data of the Server-Sent Events in body.
+func readEvents(t *testing.T, body string) []string {
+	t.Helper()
+	var events []string
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			t.Fatalf("unexpected event line %q", line)
+		}
+		events = append(events, data)
+	}
+	return events
+}
+
+func TestProgressEventStream(t *testing.T) {
+	server := newTestServer()
+	RegisterMethod(server, "import", func(ctx context.Context, rows int) (int, error) {
+		for i := 1; i <= rows; i++ {
+			if err := ReportProgress(ctx, nil, i); err != nil {
+				if errors.Is(err, ErrNotificationsNotSupported) {
+					return -1, nil
+				}
+				return 0, err
+			}
+		}
+		return rows, nil
+	})
+	handler := HTTPHandler(server)
+	eventStream := http.Header{"Accept": {"text/event-stream"}}
+
+	tests := []struct {
+		name        string
+		body        string
+		header      http.Header
+		contentType string
+		want        []string // events, or the JSON body
+	}{
+		{
+			name:        "progress then response",
+			body:        `{"jsonrpc":"2.0","method":"import","params":2,"id":7}`,
+			header:      eventStream,
+			contentType: "text/event-stream",
+			want: []string{
+				`{"jsonrpc":"2.0","method":"$/progress","params":{"token":7,"value":1}}`,
+				`{"jsonrpc":"2.0","method":"$/progress","params":{"token":7,"value":2}}`,
+				`{"jsonrpc":"2.0","result":2,"id":7}`,
+			},
+		},
+		{
+			name:        "batch progress then responses",
+			body:        `[{"jsonrpc":"2.0","method":"import","params":1,"id":1},{"jsonrpc":"2.0","method":"echo","params":"x","id":2}]`,
+			header:      eventStream,
+			contentType: "text/event-stream",
+			want: []string{
+				`{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":1}}`,
+				`[{"jsonrpc":"2.0","result":1,"id":1},{"jsonrpc":"2.0","result":"x","id":2}]`,
+			},
+		},
+		{
+			name:        "notification sends only progress",
+			body:        `{"jsonrpc":"2.0","method":"import","params":1}`,
+			header:      eventStream,
+			contentType: "text/event-stream",
+			want:        []string{`{"jsonrpc":"2.0","method":"$/progress","params":{"token":null,"value":1}}`},
+		},
+		{
+			name:        "no notification sends plain JSON",
+			body:        `{"jsonrpc":"2.0","method":"import","params":0,"id":1}`,
+			header:      eventStream,
+			contentType: "application/json",
+			want:        []string{`{"jsonrpc":"2.0","result":0,"id":1}`},
+		},
+		{
+			name:        "without accept header",
+			body:        `{"jsonrpc":"2.0","method":"import","params":2,"id":1}`,
+			contentType: "application/json",
+			want:        []string{`{"jsonrpc":"2.0","result":-1,"id":1}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postHTTP(handler, tt.body, tt.header)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.contentType)
+			}
+
+			got := []string{rec.Body.String()}
+			if tt.contentType == "text/event-stream" {
+				got = readEvents(t, rec.Body.String())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d messages %q, want %d", len(got), got, len(tt.want))
+			}
+			for i := range got {
+				assertResponses(t, []byte(got[i]), tt.want[i])
+			}
+		})
+	}
+}
+
+func TestProgressStream(t *testing.T) {
+	server := newTestServer()
+	RegisterMethod(server, "import", func(ctx context.Context, rows int) (int, error) {
+		for i := 1; i <= rows; i++ {
+			if err := ReportProgress(ctx, "import", i); err != nil {
+				return 0, err
+			}
+		}
+		return rows, nil
+	})
+	client := newStreamClient(t, context.Background(), server, FramingNewline)
+
+	t.Run("progress", func(t *testing.T) {
+		client.t = t
+		client.send(`{"jsonrpc":"2.0","method":"import","params":2,"id":1}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","method":"$/progress","params":{"token":"import","value":1}}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","method":"$/progress","params":{"token":"import","value":2}}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":2,"id":1}`)
+	})
+}
+
+func TestProgressEventStreamFlush(t *testing.T) {
+	server := NewServer()
+	release := make(chan struct{})
+	RegisterMethod(server, "export", func(ctx context.Context, p EmptyParams) (string, error) {
+		if err := ReportProgress(ctx, "export", 50); err != nil {
+			return "", err
+		}
+		<-release
+		return "done", nil
+	})
+	httpServer := httptest.NewServer(HTTPHandler(server))
+	defer httpServer.Close()
+	var releaseOnce sync.Once
+	defer releaseOnce.Do(func() { close(release) })
+
+	req, _ := http.NewRequest(http.MethodPost, httpServer.URL, strings.NewReader(`{"jsonrpc":"2.0","method":"export","id":1}`))
+	req.Header.Set("Accept", "application/json, text/event-stream;q=0.9")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if cc := resp.Header.Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("Cache-Control = %q, want no-cache", cc)
+	}
+
+	// The progress event arrives while the handler is still running.
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		t.Helper()
+		for lines.Scan() {
+			if data, ok := strings.CutPrefix(lines.Text(), "data: "); ok {
+				return data
+			}
+		}
+		t.Fatalf("event stream ended: %v", lines.Err())
+		return ""
+	}
+	assertResponses(t, []byte(next()), `{"jsonrpc":"2.0","method":"$/progress","params":{"token":"export","value":50}}`)
+	releaseOnce.Do(func() { close(release) })
+	assertResponses(t, []byte(next()), `{"jsonrpc":"2.0","result":"done","id":1}`)
+}
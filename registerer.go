@@ -3,5 +3,11 @@ package autorpc
 // Both Server and Group implement this interface.
 type Registerer interface {
 	// register is called by RegisterMethod to register a method.
-	register(name string, fn interface{}, allMiddlewares *MiddlewareChain)
+	register(name string, fn interface{}, options *methodOptions)
+
+	// rootServer returns the server methods are registered on.
+	rootServer() *Server
+
+	// methodName returns the name a method registered as name is served under.
+	methodName(name string) string
 }
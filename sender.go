@@ -0,0 +1,99 @@
+package autorpc
+
+import (
+	"context"
+	"sync"
+)
+
+// PartialResultMethod is the method of the notifications carrying the events of a stream method.
+const PartialResultMethod = "$/partialResult"
+
+// PartialResultParams are the params of PartialResultMethod notifications.
+// The token is the id of the request the event belongs to.
+type PartialResultParams struct {
+	Token interface{} `json:"token"`
+	Value interface{} `json:"value"`
+}
+
+// Sender sends the events of a stream method registered with RegisterStream.
+// It is safe for concurrent use.
+type Sender[E any] struct {
+	ctx    context.Context
+	token  interface{}
+	stream bool // events are sent as notifications, instead of collected into the result
+
+	mu     sync.Mutex
+	events []E
+}
+
+// Send sends an event to the client. It returns an error if the event could not be written,
+// or the context of the request is done, in which case the handler should return.
+func (s *Sender[E]) Send(event E) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.stream {
+		return Notify(s.ctx, PartialResultMethod, PartialResultParams{Token: s.token, Value: event})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// RegisterStream registers a method producing a stream of events. The function sends events
+// with the Sender, and returning ends the stream.
+//
+// When the client can receive notifications, each event is sent as soon as it is produced in
+// a PartialResultMethod notification, and the result of the final response is an empty array.
+// This is the case on persistent transports, and over HTTP when the request has an
+// "Accept: text/event-stream" header: the response is then a Server-Sent Events stream of
+// partial results terminated by the final response, which works behind proxies that block WebSockets.
+// Otherwise the events are collected and returned together as the result.
+//
+// The method shares the middleware chain, timeouts and cancellation of RegisterMethod.
+//
+// Example:
+//
+//	autorpc.RegisterStream(server, "logs.tail", func(ctx context.Context, params TailParams, send *autorpc.Sender[LogLine]) error {
+//	    for line := range tail(ctx, params.File) {
+//	        if err := send.Send(line); err != nil {
+//	            return err
+//	        }
+//	    }
+//	    return nil
+//	})
+func RegisterStream[P, E any](
+	r Registerer,
+	name string,
+	fn func(context.Context, P, *Sender[E]) error,
+	opts ...MethodOption,
+) {
+	handler := func(ctx context.Context, params P) ([]E, error) {
+		sender := &Sender[E]{
+			ctx:    ctx,
+			stream: notifierFromContext(ctx) != nil,
+		}
+		if req, ok := rpcRequestFromContext(ctx); ok {
+			sender.token = req.ID
+		}
+
+		if err := fn(ctx, params, sender); err != nil {
+			return nil, err
+		}
+
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+		if sender.events == nil {
+			return []E{}, nil
+		}
+		return sender.events, nil
+	}
+
+	streamOpts := append([]MethodOption{methodOptionFunc(func(o *methodOptions) {
+		o.metadata.streaming = true
+	})}, opts...)
+	RegisterMethodWithOptions(r, name, handler, streamOpts...)
+}
@@ -0,0 +1,107 @@
+package autorpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// newStreamTestServer returns a server with a "count" stream method sending 1 to n.
+// A negative n sends 1, then fails.
+func newStreamTestServer() *Server {
+	server := NewServer()
+	RegisterStream(server, "count", func(ctx context.Context, n int, send *Sender[int]) error {
+		if n < 0 {
+			if err := send.Send(1); err != nil {
+				return err
+			}
+			return NewError(1000, "count failed", nil)
+		}
+		for i := 1; i <= n; i++ {
+			if err := send.Send(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return server
+}
+
+func TestRegisterStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		http   []string // plain HTTP response
+		stream []string // messages over SSE and ServeStream
+	}{
+		{
+			name:   "events",
+			params: "2",
+			http:   []string{`{"jsonrpc":"2.0","result":[1,2],"id":1}`},
+			stream: []string{
+				`{"jsonrpc":"2.0","method":"$/partialResult","params":{"token":1,"value":1}}`,
+				`{"jsonrpc":"2.0","method":"$/partialResult","params":{"token":1,"value":2}}`,
+				`{"jsonrpc":"2.0","result":[],"id":1}`,
+			},
+		},
+		{
+			name:   "no events",
+			params: "0",
+			http:   []string{`{"jsonrpc":"2.0","result":[],"id":1}`},
+			stream: []string{`{"jsonrpc":"2.0","result":[],"id":1}`},
+		},
+		{
+			name:   "error after events",
+			params: "-1",
+			http:   []string{`{"jsonrpc":"2.0","error":{"code":1000},"id":1}`},
+			stream: []string{
+				`{"jsonrpc":"2.0","method":"$/partialResult","params":{"token":1,"value":1}}`,
+				`{"jsonrpc":"2.0","error":{"code":1000},"id":1}`,
+			},
+		},
+	}
+
+	server := newStreamTestServer()
+	handler := HTTPHandler(server)
+
+	for _, tt := range tests {
+		body := `{"jsonrpc":"2.0","method":"count","params":` + tt.params + `,"id":1}`
+
+		t.Run(tt.name+"/HTTP", func(t *testing.T) {
+			rec := postHTTP(handler, body, nil)
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+			assertResponses(t, rec.Body.Bytes(), tt.http[0])
+		})
+
+		t.Run(tt.name+"/SSE", func(t *testing.T) {
+			rec := postHTTP(handler, body, http.Header{"Accept": {"text/event-stream"}})
+			got := []string{rec.Body.String()}
+			if rec.Header().Get("Content-Type") == "text/event-stream" {
+				got = readEvents(t, rec.Body.String())
+			}
+			if len(got) != len(tt.stream) {
+				t.Fatalf("got %d messages %q, want %d", len(got), got, len(tt.stream))
+			}
+			for i := range got {
+				assertResponses(t, []byte(got[i]), tt.stream[i])
+			}
+		})
+
+		t.Run(tt.name+"/ServeStream", func(t *testing.T) {
+			client := newStreamClient(t, context.Background(), server, FramingNewline)
+			client.send(body)
+			for _, want := range tt.stream {
+				assertResponses(t, client.recv(), want)
+			}
+		})
+	}
+}
+
+func TestRegisterStreamSpec(t *testing.T) {
+	spec := newStreamTestServer().GetMethodSpecs()
+	if len(spec.Methods) != 1 || !spec.Methods[0].Streaming || spec.Methods[0].Result != "[]int" {
+		t.Errorf("methods = %+v, want one streaming method with result []int", spec.Methods)
+	}
+}
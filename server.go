@@ -1,21 +1,50 @@
 package autorpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"sync"
+	"time"
 )
 
+// methodHandler is a registered method. Everything that only depends on the function type
+// is computed once at registration, so processing a request does no reflection on types.
 type methodHandler struct {
-	fnValue     reflect.Value
-	middlewares *MiddlewareChain
+	fnValue          reflect.Value
+	paramType        reflect.Type
+	resultType       reflect.Type
+	validateParams   bool // params are a struct, checked with the server validator
+	middlewares      *MiddlewareChain
+	positionalParams []string // JSON names of the struct params fields in positional order
+	wrappedParams    bool     // params can also be sent as a one-element array
+	metadata         methodMetadata
+	strictParams     *bool
+	useNumber        *bool
+	defaults         paramDefaults // applied to the params before decoding
+	timeout          *time.Duration
+	chain            HandlerFunc // middlewares wrapped around the call of fnValue
 }
 
 type Server struct {
 	methods              sync.Map
 	validateErrorHandler ValidateErrorHandler
 	globalMiddlewares    *MiddlewareChain
+	openRPCInfo          OpenRPCInfo
+	devMode              bool
+	strictParams         bool
+	useNumber            bool
+	defaultTimeout       time.Duration
+	batchOptions         BatchOptions
+	maxBodyBytes         int64
+	jobs                 jobManager
+
+	// validate is shared by all requests so its struct cache is reused.
+	validate     Validator
+	validateOnce sync.Once
 }
 
 func NewServer() *Server {
@@ -24,10 +53,55 @@ func NewServer() *Server {
 	}
 }
 
+// validator returns the validator shared by all methods of the server.
+func (s *Server) validator() Validator {
+	s.validateOnce.Do(func() {
+		if s.validate == nil {
+			s.validate = NewValidator()
+		}
+	})
+	return s.validate
+}
+
+// SetValidator sets the validator of struct params. A nil validator restores the default one,
+// created by NewValidator.
+func (s *Server) SetValidator(v Validator) {
+	if v == nil {
+		v = NewValidator()
+	}
+	s.validate = v
+}
+
 func (s *Server) SetValidateErrorHandler(handler ValidateErrorHandler) {
 	s.validateErrorHandler = handler
 }
 
+// SetDevMode enables development checks, which are logged with the standard log package.
+// Currently it logs when a handler returns an error code that was not declared with WithErrors.
+func (s *Server) SetDevMode(enabled bool) {
+	s.devMode = enabled
+}
+
+// SetStrictParams rejects params with fields that are not in the params type, or with data after
+// the params value, instead of ignoring them. It can be overridden per method with WithStrictParams.
+func (s *Server) SetStrictParams(strict bool) {
+	s.strictParams = strict
+}
+
+// SetUseNumber decodes numbers in interface{} params values as json.Number instead of float64,
+// which keeps the precision of large integers such as ids. It can be overridden per method
+// with WithUseNumber.
+func (s *Server) SetUseNumber(useNumber bool) {
+	s.useNumber = useNumber
+}
+
+// SetMaxBodyBytes limits the size of a request body over HTTP, and of a message on WebSocket
+// and stream transports. Larger HTTP requests are rejected with CodeInvalidRequest and status 413;
+// persistent connections are closed. Zero, the default, means no limit.
+func (s *Server) SetMaxBodyBytes(n int64) {
+	s.maxBodyBytes = n
+}
+
 func (s *Server) Use(middlewares ...Middleware) {
 	for _, mw := range middlewares {
 		s.globalMiddlewares.Add(mw)
@@ -37,6 +111,7 @@ func (s *Server) Use(middlewares ...Middleware) {
 // RegisterMethod registers a method with the given name and function.
 // The first parameter can be either *Server or *Group.
 // The function must have the signature: func(context.Context, ParamsType) (ResultType, error)
+// Names starting with "rpc." are reserved by the JSON-RPC specification and cause a panic.
 //
 // Example with Server:
 //
@@ -55,58 +130,79 @@ func (s *Server) Use(middlewares ...Middleware) {
 // With middleware:
 //
 //	RegisterMethod(server, "add", AddFunc, AuthMiddleware(), LoggingMiddleware())
+//
+// Use RegisterMethodWithOptions to pass documentation and other options.
 func RegisterMethod[P, R any](
 	r Registerer,
 	name string,
 	fn func(context.Context, P) (R, error),
 	middlewares ...Middleware,
 ) {
-
-	fnValue := reflect.ValueOf(fn)
-	if fnValue.Kind() != reflect.Func {
-		panic("RegisterMethod: fn must be a function")
+	if err := validateHandlerType(reflect.TypeOf(fn)); err != nil {
+		panic("RegisterMethod: " + err.Error())
 	}
 
-	fnType := fnValue.Type()
-	if fnType.NumIn() != 2 || fnType.NumOut() != 2 {
-		panic("RegisterMethod: function must have signature func(context.Context, ParamsType) (ResultType, error)")
-	}
-
-	contextType := fnType.In(0)
-	if contextType.String() != "context.Context" {
-		panic("RegisterMethod: first parameter must be context.Context")
-	}
+	r.register(name, fn, newMethodOptions(WithMiddleware(middlewares...)))
+}
 
-	errType := fnType.Out(1)
-	if !errType.Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		panic("RegisterMethod: second return value must be error")
+// RegisterMethodWithOptions is like RegisterMethod, with method options such as documentation,
+// timeouts or decoding settings. Middleware values are method options too:
+//
+//	RegisterMethodWithOptions(server, "add", AddFunc,
+//	    WithDescription("Adds two numbers"),
+//	    WithTags("math"),
+//	    AuthMiddleware(),
+//	)
+func RegisterMethodWithOptions[P, R any](
+	r Registerer,
+	name string,
+	fn func(context.Context, P) (R, error),
+	opts ...MethodOption,
+) {
+	if err := validateHandlerType(reflect.TypeOf(fn)); err != nil {
+		panic("RegisterMethodWithOptions: " + err.Error())
 	}
 
-	middlewareChain := NewMiddlewareChain(middlewares...)
-	r.register(name, fn, middlewareChain)
+	r.register(name, fn, newMethodOptions(opts...))
 }
 
-func (s *Server) register(name string, fn interface{}, allMiddlewares *MiddlewareChain) {
-	fnValue := reflect.ValueOf(fn)
-	if fnValue.Kind() != reflect.Func {
-		panic("register: fn must be a function")
+// validateHandlerType checks that fnType is a valid method signature:
+// func(context.Context, ParamsType) (ResultType, error)
+func validateHandlerType(fnType reflect.Type) error {
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		return errors.New("fn must be a function")
 	}
 
-	fnType := fnValue.Type()
 	if fnType.NumIn() != 2 || fnType.NumOut() != 2 {
-		panic("register: function must have signature func(context.Context, ParamsType) (ResultType, error)")
+		return errors.New("function must have signature func(context.Context, ParamsType) (ResultType, error)")
 	}
 
 	contextType := fnType.In(0)
 	if contextType.String() != "context.Context" {
-		panic("register: first parameter must be context.Context")
+		return errors.New("first parameter must be context.Context")
 	}
 
 	errType := fnType.Out(1)
 	if !errType.Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-		panic("register: second return value must be error")
+		return errors.New("second return value must be error")
 	}
 
+	return nil
+}
+
+func (s *Server) register(name string, fn interface{}, options *methodOptions) {
+	if !options.reserved {
+		if err := checkMethodName(name); err != nil {
+			panic("register: " + err.Error())
+		}
+	}
+
+	fnValue := reflect.ValueOf(fn)
+	if err := validateHandlerType(fnValue.Type()); err != nil {
+		panic("register: " + err.Error())
+	}
+	fnType := fnValue.Type()
+
 	combinedMiddlewares := NewMiddlewareChain()
 
 	if s.globalMiddlewares != nil && s.globalMiddlewares.Len() > 0 {
@@ -115,19 +211,150 @@ func (s *Server) register(name string, fn interface{}, allMiddlewares *Middlewar
 		}
 	}
 
-	if allMiddlewares != nil && allMiddlewares.Len() > 0 {
-		for i := 0; i < allMiddlewares.Len(); i++ {
-			combinedMiddlewares.Add(allMiddlewares.middlewares[i])
+	if options.middlewares != nil && options.middlewares.Len() > 0 {
+		for i := 0; i < options.middlewares.Len(); i++ {
+			combinedMiddlewares.Add(options.middlewares.middlewares[i])
 		}
 	}
 
-	handler := methodHandler{
-		fnValue:     fnValue,
-		middlewares: combinedMiddlewares,
+	paramType := fnType.In(1)
+	defaults, err := newParamDefaults(paramType)
+	if err != nil {
+		panic("register: " + err.Error())
+	}
+	positional, err := positionalParams(paramType)
+	if err != nil {
+		panic("register: " + err.Error())
+	}
+
+	handler := &methodHandler{
+		fnValue:          fnValue,
+		paramType:        paramType,
+		resultType:       fnType.Out(0),
+		validateParams:   paramType.Kind() == reflect.Struct,
+		middlewares:      combinedMiddlewares,
+		positionalParams: positional,
+		wrappedParams:    acceptsWrappedParams(paramType),
+		metadata:         options.metadata,
+		strictParams:     options.strictParams,
+		useNumber:        options.useNumber,
+		defaults:         defaults,
+		timeout:          options.timeout,
 	}
+	handler.chain = handler.middlewares.Build(s.callHandler(handler))
 	s.methods.Store(name, handler)
 }
 
+func (s *Server) rootServer() *Server {
+	return s
+}
+
+func (s *Server) methodName(name string) string {
+	return name
+}
+
+// processMessage handles a raw JSON-RPC message, which can be either a single request or a batch.
+// It returns the value to send back to the client, or false if nothing must be sent
+// (a notification, or a batch made only of notifications).
+func (s *Server) processMessage(ctx context.Context, body []byte) (interface{}, bool) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return newErrorResponse(nil, CodeInvalidRequest, "Empty request"), true
+	}
+
+	switch body[0] {
+	case '[':
+		var elements []json.RawMessage
+		if err := json.Unmarshal(body, &elements); err != nil {
+			return newErrorResponse(nil, CodeParseError, "Failed to parse JSON batch"), true
+		}
+		if len(elements) == 0 {
+			return newErrorResponse(nil, CodeInvalidRequest, "Empty batch"), true
+		}
+		if resp, ok := s.checkBatchSize(len(elements)); !ok {
+			return resp, true
+		}
+
+		responses := s.processBatch(ctx, elements)
+		// If the batch only contains notifications, we must not return an empty array
+		if len(responses) == 0 {
+			return nil, false
+		}
+		return responses, true
+	case '{':
+		if !json.Valid(body) {
+			return newErrorResponse(nil, CodeParseError, "Failed to parse JSON request"), true
+		}
+		req, errResp := parseRequest(body)
+		if errResp != nil {
+			return *errResp, true
+		}
+
+		resp := s.processRequest(ctx, req)
+		// 4.1 Notification: "The Server MUST NOT reply to a Notification"
+		if req.ID == nil {
+			return nil, false
+		}
+		return resp, true
+	default:
+		return newErrorResponse(nil, CodeParseError, "Invalid JSON"), true
+	}
+}
+
+// parseRequest decodes a request object and checks its members. If it is not a valid request,
+// it returns the CodeInvalidRequest response to send, which must be sent even without an id.
+// The JSON-RPC version is checked later by processRequest.
+func parseRequest(data json.RawMessage) (RPCRequest, *RPCResponse) {
+	invalid := func(id json.RawMessage, message string) (RPCRequest, *RPCResponse) {
+		resp := newErrorResponse(id, CodeInvalidRequest, message)
+		return RPCRequest{}, &resp
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return invalid(nil, "Request must be an object")
+	}
+
+	var raw struct {
+		JSONRPC json.RawMessage `json:"jsonrpc"`
+		Method  json.RawMessage `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		ID      json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return invalid(nil, "Invalid request: "+err.Error())
+	}
+
+	if !isValidID(raw.ID) {
+		return invalid(nil, "Invalid id: must be a string, number or null")
+	}
+	if raw.Method == nil {
+		return invalid(raw.ID, "Missing method")
+	}
+
+	// Unmarshalling null into a string succeeds, so check the type first
+	req := RPCRequest{Params: raw.Params, ID: raw.ID}
+	if raw.Method[0] != '"' || json.Unmarshal(raw.Method, &req.Method) != nil {
+		return invalid(raw.ID, "Method must be a string")
+	}
+	// A version that is not a string is left empty and rejected by processRequest.
+	json.Unmarshal(raw.JSONRPC, &req.JSONRPC)
+	return req, nil
+}
+
+// isValidID reports whether id is absent, or a string, number or null as required by the spec.
+func isValidID(id json.RawMessage) bool {
+	if id == nil {
+		return true
+	}
+	switch c := id[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9':
+		return true
+	default:
+		return string(id) == "null"
+	}
+}
+
 func (s *Server) processRequest(ctx context.Context, req RPCRequest) (resp RPCResponse) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -139,56 +366,162 @@ func (s *Server) processRequest(ctx context.Context, req RPCRequest) (resp RPCRe
 		return newErrorResponse(req.ID, CodeInvalidRequest, "Invalid JSON-RPC version")
 	}
 
+	ctx = withRPCRequest(ctx, req)
+
+	if req.Method == CancelRequestMethod {
+		return processCancelRequest(ctx, req)
+	}
+
 	handlerValue, ok := s.methods.Load(req.Method)
 	if !ok {
 		return newErrorResponse(req.ID, CodeMethodNotFound, "Method not found")
 	}
 
-	handler, ok := handlerValue.(methodHandler)
+	handler, ok := handlerValue.(*methodHandler)
 	if !ok {
 		return newErrorResponse(req.ID, CodeInternalError, "Internal error: invalid method handler")
 	}
 
-	fnType := handler.fnValue.Type()
-	if fnType.NumIn() != 2 || fnType.NumOut() != 2 {
-		return newErrorResponse(req.ID, CodeInternalError, "Internal error: invalid method signature")
+	if timeout := s.methodTimeout(handler); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	contextType := fnType.In(0)
-	if contextType.String() != "context.Context" {
-		return newErrorResponse(req.ID, CodeInternalError, "Internal error: first parameter must be context.Context")
+	ctx, untrack, cancellable := trackRequest(ctx, req)
+	defer untrack()
+
+	var err error
+	if _, hasDeadline := ctx.Deadline(); hasDeadline || cancellable {
+		resp, err = s.callInterruptible(ctx, handler, req)
+	} else {
+		resp, err = handler.chain(ctx, req)
+	}
+	if err != nil {
+		if resp.Error == nil {
+			resp = newErrorResponse(req.ID, CodeInternalError, "Internal error")
+		}
+	}
+
+	return resp
+}
+
+// callInterruptible calls the middleware chain of handler in a goroutine, and returns an error
+// response as soon as the deadline of ctx is exceeded or the client cancels the request,
+// without waiting for a handler that ignores ctx.
+// Other cancellations, such as a closed connection, wait for the handler like direct calls do.
+func (s *Server) callInterruptible(ctx context.Context, handler *methodHandler, req RPCRequest) (RPCResponse, error) {
+	type result struct {
+		resp RPCResponse
+		err  error
 	}
+	done := make(chan result, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{
+					resp: newErrorResponse(req.ID, CodeInternalError, "Internal error"),
+					err:  fmt.Errorf("panic: %v", r),
+				}
+			}
+		}()
+		resp, err := handler.chain(ctx, req)
+		done <- result{resp: resp, err: err}
+	}()
 
-	finalHandler := func(ctx context.Context, req RPCRequest) (RPCResponse, error) {
-		paramType := fnType.In(1)
-		paramPtr := reflect.New(paramType).Interface()
+	var r result
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		if resp, ok := interruptedResponse(ctx, req); ok {
+			return resp, context.Cause(ctx)
+		}
+		r = <-done
+	}
 
-		if err := json.Unmarshal(req.Params, paramPtr); err != nil {
-			return newErrorResponse(req.ID, CodeInvalidParams, "Failed to unmarshal params: "+err.Error()), err
+	// A handler that honors ctx usually returns its error once ctx is done.
+	if r.err != nil && (errors.Is(r.err, context.DeadlineExceeded) || errors.Is(r.err, context.Canceled)) {
+		if resp, ok := interruptedResponse(ctx, req); ok {
+			return resp, r.err
 		}
+	}
+	return r.resp, r.err
+}
+
+// interruptedResponse returns the response to a request whose context is done because
+// its timeout elapsed or the client cancelled it.
+func interruptedResponse(ctx context.Context, req RPCRequest) (RPCResponse, bool) {
+	switch {
+	case errors.Is(context.Cause(ctx), ErrRequestCancelled):
+		return newErrorResponse(req.ID, CodeRequestCancelled, "Request cancelled"), true
+	case ctx.Err() == context.DeadlineExceeded:
+		return newErrorResponse(req.ID, CodeRequestTimeout, "Request timeout"), true
+	}
+	return RPCResponse{}, false
+}
 
-		paramValue := reflect.ValueOf(paramPtr).Elem().Interface()
+// callHandler returns the final handler of a method, which decodes and validates the params,
+// calls the method function and converts its result to a response.
+func (s *Server) callHandler(handler *methodHandler) HandlerFunc {
+	return func(ctx context.Context, req RPCRequest) (RPCResponse, error) {
+		paramPtr := reflect.New(handler.paramType)
+		handler.defaults.apply(paramPtr.Elem())
 
-		handlerFunc := s.validateErrorHandler
-		if handlerFunc == nil {
-			handlerFunc = defaultValidateErrorHandler
+		params, err := positionalToNamedParams(req.Params, handler.positionalParams)
+		if err != nil {
+			return newErrorResponse(req.ID, CodeInvalidParams, "Invalid positional params: "+err.Error()), err
 		}
 
-		if validationErr := validateParams(paramValue, handlerFunc); validationErr != nil {
-			return RPCResponse{
-				JSONRPC: "2.0",
-				Error:   validationErr,
-				ID:      req.ID,
-			}, nil
+		opts := s.decodeOptions(handler)
+		err = decodeParams(params, paramPtr.Interface(), opts)
+		// By-position clients send non-struct params as the only element of an array.
+		if err != nil && handler.wrappedParams {
+			if element, ok := unwrapParams(params); ok {
+				wrapped := reflect.New(handler.paramType)
+				if decodeParams(element, wrapped.Interface(), opts) == nil {
+					paramPtr, err = wrapped, nil
+				}
+			}
+		}
+		if err != nil {
+			resp := newErrorResponse(req.ID, CodeInvalidParams, "Failed to unmarshal params: "+err.Error())
+			data := map[string]any{"error": err.Error()}
+			if path := paramsErrorPath(err); path != "" {
+				data["path"] = path
+			}
+			resp.Error.Data = data
+			return resp, err
 		}
 
-		results := handler.fnValue.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(paramValue)})
+		paramValue := paramPtr.Elem()
+		handler.defaults.applyMaps(paramValue)
+
+		if handler.validateParams {
+			handlerFunc := s.validateErrorHandler
+			if handlerFunc == nil {
+				handlerFunc = defaultValidateErrorHandler
+			}
+
+			if validationErr := validateParams(s.validator(), paramValue.Interface(), handlerFunc); validationErr != nil {
+				return RPCResponse{
+					JSONRPC: "2.0",
+					Error:   validationErr,
+					ID:      req.ID,
+				}, nil
+			}
+		}
+
+		results := handler.fnValue.Call([]reflect.Value{reflect.ValueOf(ctx), paramValue})
 		resultValue := results[0].Interface()
 		errValue := results[1].Interface()
 
 		if errValue != nil {
 			if err, ok := errValue.(error); ok && err != nil {
 				rpcErr := errorToRPCError(err)
+				if s.devMode {
+					checkDeclaredError(req.Method, handler, err)
+				}
 				return RPCResponse{
 					JSONRPC: "2.0",
 					Error:   rpcErr,
@@ -203,19 +536,28 @@ func (s *Server) processRequest(ctx context.Context, req RPCRequest) (resp RPCRe
 			ID:      req.ID,
 		}, nil
 	}
+}
 
-	chainHandler := finalHandler
-
-	if handler.middlewares != nil && handler.middlewares.Len() > 0 {
-		chainHandler = handler.middlewares.Build(finalHandler)
+// decodeOptions returns how the params of handler are decoded.
+func (s *Server) decodeOptions(handler *methodHandler) decodeOptions {
+	opts := decodeOptions{strict: s.strictParams, useNumber: s.useNumber}
+	if handler.strictParams != nil {
+		opts.strict = *handler.strictParams
 	}
-
-	resp, err := chainHandler(ctx, req)
-	if err != nil {
-		if resp.Error == nil {
-			resp = newErrorResponse(req.ID, CodeInternalError, "Internal error")
-		}
+	if handler.useNumber != nil {
+		opts.useNumber = *handler.useNumber
 	}
+	return opts
+}
 
-	return resp
+type rpcRequestKey struct{}
+
+func withRPCRequest(ctx context.Context, req RPCRequest) context.Context {
+	return context.WithValue(ctx, rpcRequestKey{}, req)
+}
+
+// rpcRequestFromContext returns the request currently being processed.
+func rpcRequestFromContext(ctx context.Context) (RPCRequest, bool) {
+	req, ok := ctx.Value(rpcRequestKey{}).(RPCRequest)
+	return req, ok
 }
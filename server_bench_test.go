@@ -0,0 +1,66 @@
+package autorpc
+
+import (
+	"context"
+	"testing"
+)
+
+type benchParams struct {
+	Name  string `json:"name" validate:"required,min=2"`
+	Email string `json:"email" validate:"required,email"`
+	Age   int    `json:"age" validate:"gte=0,lte=150"`
+}
+
+type benchResult struct {
+	Greeting string `json:"greeting"`
+}
+
+func newBenchServer() *Server {
+	server := NewServer()
+	RegisterMethod(server, "greet", func(ctx context.Context, p benchParams) (benchResult, error) {
+		return benchResult{Greeting: "Hello, " + p.Name}, nil
+	})
+	RegisterMethod(server, "double", func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	})
+	return server
+}
+
+func BenchmarkProcessRequest(b *testing.B) {
+	benchmarks := []struct {
+		name string
+		body string
+	}{
+		{
+			name: "struct",
+			body: `{"jsonrpc":"2.0","method":"greet","params":{"name":"Ada","email":"ada@example.com","age":36},"id":1}`,
+		},
+		{
+			name: "scalar",
+			body: `{"jsonrpc":"2.0","method":"double","params":21,"id":1}`,
+		},
+		{
+			name: "batch",
+			body: `[` +
+				`{"jsonrpc":"2.0","method":"greet","params":{"name":"Ada","email":"ada@example.com","age":36},"id":1},` +
+				`{"jsonrpc":"2.0","method":"double","params":21,"id":2},` +
+				`{"jsonrpc":"2.0","method":"greet","params":{"name":"Alan","email":"alan@example.com","age":41},"id":3},` +
+				`{"jsonrpc":"2.0","method":"double","params":4,"id":4}` +
+				`]`,
+		},
+	}
+
+	server := newBenchServer()
+	ctx := context.Background()
+	for _, bm := range benchmarks {
+		body := []byte(bm.body)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, ok := server.processMessage(ctx, body); !ok {
+					b.Fatal("no response")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+type addParams struct {
+	A int `json:"a" validate:"required"`
+	B int `json:"b"`
+}
+
+// newTestServer returns a server with the methods used by the transport tests.
+func newTestServer() *Server {
+	server := NewServer()
+	server.SetBatchOptions(BatchOptions{PreserveOrder: true})
+	RegisterMethod(server, "echo", func(ctx context.Context, s string) (string, error) {
+		return s, nil
+	})
+	RegisterMethod(server, "add", func(ctx context.Context, p addParams) (int, error) {
+		return p.A + p.B, nil
+	})
+	RegisterMethod(server, "fail", func(ctx context.Context, p EmptyParams) (int, error) {
+		return 0, NewError(1000, "failed", nil)
+	})
+	return server
+}
+
+// protocolTests are single messages whose responses do not depend on the transport.
+// An empty want means no response. status is the HTTP status of the response.
+var protocolTests = []struct {
+	name   string
+	body   string
+	want   string
+	status int
+}{
+	{
+		name:   "call",
+		body:   `{"jsonrpc":"2.0","method":"add","params":{"a":1,"b":2},"id":1}`,
+		want:   `{"jsonrpc":"2.0","result":3,"id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "positional params",
+		body:   `{"jsonrpc":"2.0","method":"add","params":[1,2],"id":"a"}`,
+		want:   `{"jsonrpc":"2.0","result":3,"id":"a"}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "wrapped params",
+		body:   `{"jsonrpc":"2.0","method":"echo","params":["hi"],"id":1}`,
+		want:   `{"jsonrpc":"2.0","result":"hi","id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "notification",
+		body:   `{"jsonrpc":"2.0","method":"echo","params":"hi"}`,
+		status: http.StatusNoContent,
+	},
+	{
+		name:   "handler error",
+		body:   `{"jsonrpc":"2.0","method":"fail","id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":1000},"id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "invalid params",
+		body:   `{"jsonrpc":"2.0","method":"add","params":{"b":2},"id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32602},"id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "method not found",
+		body:   `{"jsonrpc":"2.0","method":"missing","id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32601},"id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "missing method",
+		body:   `{"jsonrpc":"2.0","id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "null method",
+		body:   `{"jsonrpc":"2.0","method":null,"id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "number method",
+		body:   `{"jsonrpc":"2.0","method":1,"id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "object id",
+		body:   `{"jsonrpc":"2.0","method":"echo","params":"hi","id":{}}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":null}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "wrong version",
+		body:   `{"jsonrpc":"1.0","method":"echo","params":"hi","id":1}`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "parse error",
+		body:   `{"jsonrpc":"2.0","method":`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32700},"id":null}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name:   "empty batch",
+		body:   `[]`,
+		want:   `{"jsonrpc":"2.0","error":{"code":-32600},"id":null}`,
+		status: http.StatusBadRequest,
+	},
+	{
+		name: "batch with invalid elements",
+		body: `[
+			{"jsonrpc":"2.0","method":"echo","params":"a","id":1},
+			1,
+			{"jsonrpc":"2.0","method":"echo","params":"b","id":{}},
+			{"jsonrpc":"2.0","id":4},
+			{"jsonrpc":"2.0","method":null,"id":5},
+			{"jsonrpc":"2.0","method":"echo","params":"notified"},
+			{"jsonrpc":"2.0","method":"echo","params":"f","id":6}
+		]`,
+		want: `[
+			{"jsonrpc":"2.0","result":"a","id":1},
+			{"jsonrpc":"2.0","error":{"code":-32600},"id":null},
+			{"jsonrpc":"2.0","error":{"code":-32600},"id":null},
+			{"jsonrpc":"2.0","error":{"code":-32600},"id":4},
+			{"jsonrpc":"2.0","error":{"code":-32600},"id":5},
+			{"jsonrpc":"2.0","result":"f","id":6}
+		]`,
+		status: http.StatusOK,
+	},
+	{
+		name:   "batch of notifications",
+		body:   `[{"jsonrpc":"2.0","method":"echo","params":"a"},{"jsonrpc":"2.0","method":"echo","params":"b"}]`,
+		status: http.StatusNoContent,
+	},
+}
+
+// normalizeResponses decodes a single response or a batch, keeping only the code of errors
+// so that tests do not depend on error messages.
+func normalizeResponses(t *testing.T, data []byte) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+
+	normalize := func(resp interface{}) {
+		if obj, ok := resp.(map[string]interface{}); ok {
+			if rpcErr, ok := obj["error"].(map[string]interface{}); ok {
+				obj["error"] = map[string]interface{}{"code": rpcErr["code"]}
+			}
+		}
+	}
+	if batch, ok := v.([]interface{}); ok {
+		for _, resp := range batch {
+			normalize(resp)
+		}
+	} else {
+		normalize(v)
+	}
+	return v
+}
+
+func assertResponses(t *testing.T, got []byte, want string) {
+	t.Helper()
+	if g, w := normalizeResponses(t, got), normalizeResponses(t, []byte(want)); !reflect.DeepEqual(g, w) {
+		t.Errorf("response = %s, want %s", got, want)
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantMethod string
+		wantError  string
+	}{
+		{name: "valid", data: `{"jsonrpc":"2.0","method":"echo","id":1}`, wantMethod: "echo"},
+		{name: "no id", data: `{"jsonrpc":"2.0","method":"echo"}`, wantMethod: "echo"},
+		{name: "not an object", data: `[]`, wantError: "Request must be an object"},
+		{name: "missing method", data: `{"id":1}`, wantError: "Missing method"},
+		{name: "null method", data: `{"method":null,"id":1}`, wantError: "Method must be a string"},
+		{name: "number method", data: `{"method":1,"id":1}`, wantError: "Method must be a string"},
+		{name: "object method", data: `{"method":{},"id":1}`, wantError: "Method must be a string"},
+		{name: "object id", data: `{"method":"echo","id":{}}`, wantError: "Invalid id: must be a string, number or null"},
+		{name: "bool id", data: `{"method":"echo","id":true}`, wantError: "Invalid id: must be a string, number or null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, resp := parseRequest(json.RawMessage(tt.data))
+			if tt.wantError != "" {
+				if resp == nil || resp.Error.Code != CodeInvalidRequest || resp.Error.Message != tt.wantError {
+					t.Fatalf("response = %+v, want %q", resp, tt.wantError)
+				}
+				return
+			}
+			if resp != nil {
+				t.Fatalf("unexpected error response: %+v", resp.Error)
+			}
+			if req.Method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", req.Method, tt.wantMethod)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package autorpc
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"unicode"
+)
+
+// ServiceNamer can be implemented by services passed to RegisterService to choose
+// the RPC name of their methods. The prefix is prepended to the returned name.
+// Returning an empty string skips the method.
+type ServiceNamer interface {
+	RPCMethodName(method string) string
+}
+
+// RegisterService registers every exported method of svc with the signature
+// func(context.Context, ParamsType) (ResultType, error) as prefix + lowerCamel(MethodName),
+// or prefix + svc.RPCMethodName(MethodName) if svc implements ServiceNamer.
+// Methods of svc are looked up on its dynamic type, so pass a pointer to register
+// methods with pointer receivers.
+//
+// If any method has another signature, an invalid default or rpc tag, or a reserved "rpc." name,
+// RegisterService returns an error describing every invalid method and registers nothing.
+//
+// Example:
+//
+//	type MathService struct{}
+//
+//	func (s *MathService) Add(ctx context.Context, params BinaryOpParams) (float32, error) { ... }
+//
+//	err := autorpc.RegisterService(server, "math.", &MathService{}) // registers "math.add"
+func RegisterService(r Registerer, prefix string, svc any, opts ...MethodOption) error {
+	svcValue := reflect.ValueOf(svc)
+	if !svcValue.IsValid() {
+		return errors.New("RegisterService: svc must not be nil")
+	}
+	svcType := svcValue.Type()
+
+	namer, hasNamer := svc.(ServiceNamer)
+
+	type serviceMethod struct {
+		name string
+		fn   interface{}
+	}
+	var methods []serviceMethod
+	var errs []error
+
+	for i := 0; i < svcType.NumMethod(); i++ {
+		method := svcType.Method(i)
+		if hasNamer && method.Name == "RPCMethodName" {
+			continue
+		}
+
+		name := lowerCamelCase(method.Name)
+		if hasNamer {
+			name = namer.RPCMethodName(method.Name)
+			if name == "" {
+				continue
+			}
+		}
+
+		if err := checkMethodName(r.methodName(prefix + name)); err != nil {
+			errs = append(errs, fmt.Errorf("RegisterService: method %s.%s: %w", svcType, method.Name, err))
+			continue
+		}
+
+		fnValue := svcValue.Method(i)
+		if err := validateHandlerType(fnValue.Type()); err != nil {
+			errs = append(errs, fmt.Errorf("RegisterService: method %s.%s: %w", svcType, method.Name, err))
+			continue
+		}
+		if _, err := newParamDefaults(fnValue.Type().In(1)); err != nil {
+			errs = append(errs, fmt.Errorf("RegisterService: method %s.%s: %w", svcType, method.Name, err))
+			continue
+		}
+		if _, err := positionalParams(fnValue.Type().In(1)); err != nil {
+			errs = append(errs, fmt.Errorf("RegisterService: method %s.%s: %w", svcType, method.Name, err))
+			continue
+		}
+
+		methods = append(methods, serviceMethod{name: prefix + name, fn: fnValue.Interface()})
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if len(methods) == 0 {
+		return fmt.Errorf("RegisterService: %s has no exported methods", svcType)
+	}
+
+	for _, method := range methods {
+		r.register(method.name, method.fn, newMethodOptions(opts...))
+	}
+	return nil
+}
+
+// lowerCamelCase lowercases the leading uppercase letters of a Go identifier:
+// "Add" -> "add", "GetUser" -> "getUser", "HTTPStatus" -> "httpStatus", "ID" -> "id".
+func lowerCamelCase(name string) string {
+	runes := []rune(name)
+	for i := 0; i < len(runes) && unicode.IsUpper(runes[i]); i++ {
+		// Keep the last letter of an acronym uppercase when it starts the next word
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,107 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// session is a persistent, bidirectional connection with a single client.
+// Incoming messages are processed concurrently, while outgoing messages are
+// serialized so that the underlying transport only ever sees one writer.
+type session struct {
+	server  *Server
+	ctx     context.Context
+	cancel  context.CancelFunc
+	write   func(data []byte) error
+	writeMu sync.Mutex
+	wg      sync.WaitGroup
+
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]context.CancelFunc
+
+	inflight inflightRequests
+}
+
+// newSession creates a session bound to ctx. The write function is called
+// with one complete JSON-RPC message (single response or batch) at a time.
+func newSession(ctx context.Context, server *Server, write func(data []byte) error) *session {
+	s := &session{
+		server:        server,
+		write:         write,
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+	ctx = withInflightRequests(withNotifier(ctx, s), &s.inflight)
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return s
+}
+
+// handle processes an incoming message in its own goroutine and writes the response, if any.
+func (s *session) handle(data []byte) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		hooks := &responseHooks{}
+		defer hooks.run()
+
+		resp, ok := s.server.processMessage(withResponseHooks(s.ctx, hooks), data)
+		if !ok {
+			return
+		}
+		s.send(resp)
+	}()
+}
+
+// send encodes v as JSON and writes it to the client.
+func (s *session) send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return s.write(data)
+}
+
+func (s *session) notify(method string, params interface{}) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.send(RPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// addSubscription stores the cancel function of a subscription and returns its id.
+func (s *session) addSubscription(cancel context.CancelFunc) string {
+	id := newRandomID()
+
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	s.subscriptions[id] = cancel
+	return id
+}
+
+// removeSubscription cancels the subscription with the given id.
+// It reports whether the subscription existed.
+func (s *session) removeSubscription(id string) bool {
+	s.subscriptionsMu.Lock()
+	cancel, ok := s.subscriptions[id]
+	delete(s.subscriptions, id)
+	s.subscriptionsMu.Unlock()
+
+	if ok {
+		cancel()
+	}
+	return ok
+}
+
+// close cancels the context of all in-flight requests and subscriptions and waits for the requests to return.
+func (s *session) close() {
+	s.cancel()
+	s.wg.Wait()
+}
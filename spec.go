@@ -3,26 +3,29 @@ package autorpc
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
 )
 
 type FieldInfo struct {
-	Name            string      `json:"name"`
-	JSONName        string      `json:"jsonName,omitempty"`
-	Type            string      `json:"type"`
-	Kind            string      `json:"kind"`
-	Required        bool        `json:"required,omitempty"`
-	ValidationRules []string    `json:"validationRules,omitempty"`
-	IsArray         bool        `json:"isArray,omitempty"`
-	ArrayDepth      int         `json:"arrayDepth,omitempty"` // 0 = not an array, 1 = []T, 2 = [][]T, etc.
-	IsPointer       bool        `json:"isPointer,omitempty"`
-	PointerDepth    int         `json:"pointerDepth,omitempty"` // 0 = not a pointer, 1 = *T, 2 = **T, etc.
-	ElementType     string      `json:"elementType,omitempty"`  // string representation of element type (hint)
-	KeyType         string      `json:"keyType,omitempty"`      // key type for map types
-	ValueType       string      `json:"valueType,omitempty"`    // value type for map types
-	Fields          []FieldInfo `json:"fields,omitempty"`       // nested fields if this is a struct type
+	Name            string          `json:"name"`
+	JSONName        string          `json:"jsonName,omitempty"`
+	Type            string          `json:"type"`
+	Kind            string          `json:"kind"`
+	Required        bool            `json:"required,omitempty"`
+	Description     string          `json:"description,omitempty"` // from the `desc` struct tag
+	Default         json.RawMessage `json:"default,omitempty"`     // from the `default` struct tag
+	ValidationRules []string        `json:"validationRules,omitempty"`
+	IsArray         bool            `json:"isArray,omitempty"`
+	ArrayDepth      int             `json:"arrayDepth,omitempty"` // 0 = not an array, 1 = []T, 2 = [][]T, etc.
+	IsPointer       bool            `json:"isPointer,omitempty"`
+	PointerDepth    int             `json:"pointerDepth,omitempty"` // 0 = not a pointer, 1 = *T, 2 = **T, etc.
+	ElementType     string          `json:"elementType,omitempty"`  // string representation of element type (hint)
+	KeyType         string          `json:"keyType,omitempty"`      // key type for map types
+	ValueType       string          `json:"valueType,omitempty"`    // value type for map types
+	Fields          []FieldInfo     `json:"fields,omitempty"`       // nested fields if this is a struct type
 }
 
 type TypeInfo struct {
@@ -40,14 +43,29 @@ type TypeInfo struct {
 }
 
 type MethodInfo struct {
-	Name   string `json:"name"`
-	Params string `json:"params"` // name of the type
-	Result string `json:"result"` // name of the type
+	Name              string          `json:"name"`
+	Params            string          `json:"params"`               // name of the type
+	Result            string          `json:"result"`               // name of the type
+	ParamOrder        []string        `json:"paramOrder,omitempty"` // JSON names of the struct params fields accepted by position, in order
+	Description       string          `json:"description,omitempty"`
+	Tags              []string        `json:"tags,omitempty"`
+	Examples          []MethodExample `json:"examples,omitempty"`
+	Deprecated        bool            `json:"deprecated,omitempty"`
+	DeprecationReason string          `json:"deprecationReason,omitempty"`
+	Errors            []ErrorInfo     `json:"errors,omitempty"`    // errors declared with WithErrors
+	Streaming         bool            `json:"streaming,omitempty"` // registered with RegisterStream; result is the array of events
+}
+
+type ErrorInfo struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	DataType string `json:"dataType,omitempty"` // name of the type of the error data
 }
 
 type ServerSpec struct {
-	Methods []MethodInfo        `json:"methods"`
-	Types   map[string]TypeInfo `json:"types"`
+	Methods []MethodInfo           `json:"methods"`
+	Types   map[string]TypeInfo    `json:"types"`
+	Schemas map[string]*JSONSchema `json:"schemas"` // JSON Schema (draft 2020-12) of the named structs in Types, referenced as "#/schemas/<name>"
 }
 
 // GetMethodSpecs returns information about all registered RPC methods.
@@ -68,26 +86,49 @@ type ServerSpec struct {
 func (s *Server) GetMethodSpecs() ServerSpec {
 	types := make(map[string]TypeInfo)
 	var methods []MethodInfo
+	schemas := newSchemaBuilder("#/schemas/", true)
 
 	s.methods.Range(func(key, value interface{}) bool {
 		methodName := key.(string)
-		handler := value.(methodHandler)
-
-		fnType := handler.fnValue.Type()
+		handler := value.(*methodHandler)
 
-		paramType := fnType.In(1)
-		resultType := fnType.Out(0)
+		paramType := handler.paramType
+		resultType := handler.resultType
 
 		collectStructTypes(paramType, types)
 		collectStructTypes(resultType, types)
+		schemas.schemaFor(paramType)
+		schemas.schemaFor(resultType)
 
 		paramInfo := extractTypeInfo(paramType)
 		resultInfo := extractTypeInfo(resultType)
 
 		method := MethodInfo{
-			Name:   methodName,
-			Params: buildFullTypeName(paramInfo),
-			Result: buildFullTypeName(resultInfo),
+			Name:       methodName,
+			Params:     buildFullTypeName(paramInfo),
+			Result:     buildFullTypeName(resultInfo),
+			ParamOrder: handler.positionalParams,
+
+			Description:       handler.metadata.description,
+			Tags:              handler.metadata.tags,
+			Examples:          handler.metadata.examples,
+			Deprecated:        handler.metadata.deprecated,
+			DeprecationReason: handler.metadata.deprecationReason,
+			Streaming:         handler.metadata.streaming,
+		}
+
+		for _, declared := range handler.metadata.errors {
+			errorInfo := ErrorInfo{
+				Code:    declared.Code(),
+				Message: declared.Message(),
+			}
+			if data := declared.Data(); data != nil {
+				dataType := reflect.TypeOf(data)
+				collectStructTypes(dataType, types)
+				schemas.schemaFor(dataType)
+				errorInfo.DataType = buildFullTypeName(extractTypeInfo(dataType))
+			}
+			method.Errors = append(method.Errors, errorInfo)
 		}
 
 		methods = append(methods, method)
@@ -97,6 +138,7 @@ func (s *Server) GetMethodSpecs() ServerSpec {
 	return ServerSpec{
 		Methods: methods,
 		Types:   types,
+		Schemas: schemas.defs,
 	}
 }
 
@@ -174,18 +216,8 @@ func collectStructTypes(typ reflect.Type, types map[string]TypeInfo) {
 		info := extractTypeInfo(typ)
 		types[typeName] = info
 
-		for i := 0; i < typ.NumField(); i++ {
-			field := typ.Field(i)
-			if !field.IsExported() {
-				continue
-			}
-
-			jsonTag := field.Tag.Get("json")
-			if jsonTag == "-" {
-				continue
-			}
-
-			fieldType := stripArraysAndPointers(field.Type)
+		for _, f := range jsonFields(typ) {
+			fieldType := stripArraysAndPointers(f.field.Type)
 
 			if fieldType.Kind() == reflect.Struct {
 				collectStructTypes(fieldType, types)
@@ -280,37 +312,21 @@ func extractTypeInfo(typ reflect.Type) TypeInfo {
 func extractFields(typ reflect.Type) []FieldInfo {
 	var fields []FieldInfo
 
-	for i := 0; i < typ.NumField(); i++ {
-		field := typ.Field(i)
-
-		if !field.IsExported() {
-			continue
-		}
+	// Fields of embedded structs are promoted, as encoding/json does.
+	for _, f := range jsonFields(typ) {
+		field := f.field
 
 		fieldInfo := FieldInfo{
-			Name: field.Name,
-		}
-
-		jsonTag := field.Tag.Get("json")
-		if jsonTag == "-" {
-			continue
-		}
-		if jsonTag != "" {
-			parts := strings.Split(jsonTag, ",")
-			fieldInfo.JSONName = parts[0]
-		} else {
-			fieldInfo.JSONName = field.Name
+			Name:        field.Name,
+			JSONName:    f.name,
+			Description: field.Tag.Get("desc"),
+			Default:     fieldDefaultJSON(field),
 		}
 
 		validateTag := field.Tag.Get("validate")
 		if validateTag != "" {
 			fieldInfo.ValidationRules = strings.Split(validateTag, ",")
-			for _, rule := range fieldInfo.ValidationRules {
-				if strings.HasPrefix(strings.TrimSpace(rule), "required") {
-					fieldInfo.Required = true
-					break
-				}
-			}
+			fieldInfo.Required = isRequiredRule(fieldInfo.ValidationRules)
 		}
 
 		fieldType := field.Type
@@ -0,0 +1,95 @@
+package autorpc
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// errEventStreamClosed is returned when a notification is sent after the response of an
+// HTTP request answered with Server-Sent Events was written.
+var errEventStreamClosed = errors.New("autorpc: event stream closed")
+
+// sseStream sends the notifications of an HTTP request as Server-Sent Events,
+// followed by the response. Each event carries one JSON-RPC message in its data field.
+//
+// The stream starts with the first notification. Until then the response can still be
+// written as a regular JSON body with any status, which is what happens for requests
+// that send no notification.
+type sseStream struct {
+	w       http.ResponseWriter
+	mu      sync.Mutex
+	started bool
+	closed  bool
+}
+
+// wantsEventStream reports whether the client accepts Server-Sent Events for r.
+func wantsEventStream(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *sseStream) notify(method string, params interface{}) error {
+	return s.send(RPCNotification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	}, false)
+}
+
+// send writes v as an event, starting the stream if needed. If last is true,
+// the stream is closed after v.
+func (s *sseStream) send(v interface{}, last bool) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errEventStreamClosed
+	}
+	if last {
+		s.closed = true
+	}
+
+	if !s.started {
+		s.started = true
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+	}
+
+	if _, err := s.w.Write([]byte("data: " + string(data) + "\n\n")); err != nil {
+		return err
+	}
+	if flusher, ok := s.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// finish closes the stream. If it started, v is written as the last event when hasResponse
+// is true, and finish returns true. Otherwise the caller writes the response as a regular JSON body.
+func (s *sseStream) finish(v interface{}, hasResponse bool) bool {
+	s.mu.Lock()
+	if !s.started || !hasResponse {
+		started := s.started
+		s.closed = true
+		s.mu.Unlock()
+		return started
+	}
+	s.mu.Unlock()
+
+	s.send(v, true)
+	return true
+}
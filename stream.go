@@ -0,0 +1,177 @@
+package autorpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// Framing defines how JSON-RPC messages are delimited on a stream.
+type Framing int
+
+const (
+	// FramingNewline delimits messages with a newline. Messages must not contain raw newlines,
+	// which is always the case for JSON produced by encoding/json.
+	FramingNewline Framing = iota
+	// FramingContentLength precedes each message with a "Content-Length: N" header block
+	// terminated by an empty line, like the Language Server Protocol.
+	FramingContentLength
+)
+
+type StreamOptions struct {
+	Framing Framing
+}
+
+// ServeStream serves JSON-RPC requests read from r and writes the responses to w,
+// using the framing given in opts. Requests are processed concurrently and writes are serialized,
+// so responses may be written out of order and must be matched by id.
+// Notifications and subscriptions are supported, like on WebSocketHandler.
+//
+// ServeStream returns nil when r reaches EOF, after in-flight requests completed.
+// If ctx is cancelled, it cancels the in-flight requests and returns ctx.Err() without waiting
+// for a pending read on r to return.
+//
+// Example, serving a tool spawned by an editor:
+//
+//	err := autorpc.ServeStream(ctx, server, os.Stdin, os.Stdout, autorpc.StreamOptions{
+//	    Framing: autorpc.FramingContentLength,
+//	})
+func ServeStream(ctx context.Context, server *Server, r io.Reader, w io.Writer, opts StreamOptions) error {
+	reader := newFrameReader(r, opts.Framing, server.maxBodyBytes)
+	writer := newFrameWriter(w, opts.Framing)
+
+	sess := newSession(ctx, server, writer)
+	defer sess.close()
+
+	type frame struct {
+		data []byte
+		err  error
+	}
+	frames := make(chan frame)
+
+	go func() {
+		for {
+			data, err := reader()
+			select {
+			case frames <- frame{data: data, err: err}:
+			case <-sess.ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case f := <-frames:
+			if f.err != nil {
+				if errors.Is(f.err, io.EOF) {
+					// Let in-flight requests complete before the deferred close cancels them.
+					sess.wg.Wait()
+					return nil
+				}
+				return f.err
+			}
+			sess.handle(f.data)
+		}
+	}
+}
+
+// ErrMessageTooLarge is returned by ServeStream when a message exceeds Server.SetMaxBodyBytes.
+var ErrMessageTooLarge = errors.New("autorpc: message too large")
+
+// newFrameReader returns a function reading one message at a time from r.
+// Messages larger than maxBytes fail with ErrMessageTooLarge, unless maxBytes is zero.
+func newFrameReader(r io.Reader, framing Framing, maxBytes int64) func() ([]byte, error) {
+	br := bufio.NewReader(r)
+
+	if framing == FramingContentLength {
+		tp := textproto.NewReader(br)
+		return func() ([]byte, error) {
+			header, err := tp.ReadMIMEHeader()
+			if err != nil {
+				if len(header) == 0 && errors.Is(err, io.EOF) {
+					return nil, io.EOF
+				}
+				return nil, err
+			}
+
+			length, err := strconv.Atoi(strings.TrimSpace(header.Get("Content-Length")))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("autorpc: invalid Content-Length header %q", header.Get("Content-Length"))
+			}
+			if maxBytes > 0 && int64(length) > maxBytes {
+				return nil, ErrMessageTooLarge
+			}
+
+			data := make([]byte, length)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return nil, err
+			}
+			return data, nil
+		}
+	}
+
+	return func() ([]byte, error) {
+		for {
+			line, err := readLine(br, maxBytes)
+			if errors.Is(err, ErrMessageTooLarge) {
+				return nil, err
+			}
+			line = bytes.TrimSpace(line)
+			if len(line) > 0 {
+				// A last message without a trailing newline is still a message.
+				return line, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readLine reads until the next newline like bufio.Reader.ReadBytes, but fails with
+// ErrMessageTooLarge instead of buffering a line longer than maxBytes.
+func readLine(br *bufio.Reader, maxBytes int64) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if maxBytes > 0 && int64(len(bytes.TrimSpace(line))) > maxBytes {
+			return nil, ErrMessageTooLarge
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		return line, err
+	}
+}
+
+// newFrameWriter returns a function writing one message to w with the given framing.
+// It is not safe for concurrent use; sessions serialize calls.
+func newFrameWriter(w io.Writer, framing Framing) func([]byte) error {
+	if framing == FramingContentLength {
+		return func(data []byte) error {
+			if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+				return err
+			}
+			_, err := w.Write(data)
+			return err
+		}
+	}
+
+	return func(data []byte) error {
+		_, err := w.Write(append(data, '\n'))
+		return err
+	}
+}
@@ -0,0 +1,321 @@
+package autorpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamClient is the client side of a ServeStream session over pipes.
+type streamClient struct {
+	t        *testing.T
+	requests *io.PipeWriter
+	write    func([]byte) error
+	messages chan []byte
+	done     chan error // result of ServeStream
+}
+
+func newStreamClient(t *testing.T, ctx context.Context, server *Server, framing Framing) *streamClient {
+	t.Helper()
+	requestsR, requestsW := io.Pipe()
+	responsesR, responsesW := io.Pipe()
+
+	c := &streamClient{
+		t:        t,
+		requests: requestsW,
+		write:    newFrameWriter(requestsW, framing),
+		messages: make(chan []byte, 16),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- ServeStream(ctx, server, requestsR, responsesW, StreamOptions{Framing: framing})
+		responsesW.Close()
+	}()
+	go func() {
+		defer close(c.messages)
+		read := newFrameReader(responsesR, framing, 0)
+		for {
+			data, err := read()
+			if err != nil {
+				return
+			}
+			c.messages <- data
+		}
+	}()
+
+	t.Cleanup(func() {
+		requestsW.Close()
+		responsesR.Close()
+	})
+	return c
+}
+
+func (c *streamClient) send(message string) {
+	c.t.Helper()
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, []byte(message)); err == nil {
+		message = compact.String()
+	}
+	if err := c.write([]byte(message)); err != nil {
+		c.t.Fatalf("write: %v", err)
+	}
+}
+
+func (c *streamClient) recv() []byte {
+	c.t.Helper()
+	select {
+	case data, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("stream closed")
+		}
+		return data
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+// close ends the input stream and returns the result of ServeStream.
+func (c *streamClient) close() error {
+	c.t.Helper()
+	c.requests.Close()
+	select {
+	case err := <-c.done:
+		return err
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("ServeStream did not return")
+		return nil
+	}
+}
+
+const pingRequest = `{"jsonrpc":"2.0","method":"echo","params":"ping","id":"ping"}`
+const pingResponse = `{"jsonrpc":"2.0","result":"ping","id":"ping"}`
+
+func TestServeStream(t *testing.T) {
+	for _, framing := range []Framing{FramingNewline, FramingContentLength} {
+		client := newStreamClient(t, context.Background(), newTestServer(), framing)
+
+		for _, tt := range protocolTests {
+			t.Run(tt.name, func(t *testing.T) {
+				client.t = t
+				client.send(tt.body)
+				want := tt.want
+				if want == "" {
+					// Nothing is sent back, so the next message answers the ping
+					client.send(pingRequest)
+					want = pingResponse
+				}
+				assertResponses(t, client.recv(), want)
+			})
+		}
+
+		client.t = t
+		if err := client.close(); err != nil {
+			t.Errorf("ServeStream = %v, want nil", err)
+		}
+	}
+}
+
+func TestServeStreamConcurrentRequests(t *testing.T) {
+	server := newTestServer()
+	release := make(chan struct{})
+	RegisterMethod(server, "wait", func(ctx context.Context, p EmptyParams) (string, error) {
+		<-release
+		return "released", nil
+	})
+	RegisterMethod(server, "release", func(ctx context.Context, p EmptyParams) (bool, error) {
+		close(release)
+		return true, nil
+	})
+	client := newStreamClient(t, context.Background(), server, FramingNewline)
+
+	client.send(`{"jsonrpc":"2.0","method":"wait","id":1}`)
+	client.send(`{"jsonrpc":"2.0","method":"release","id":2}`)
+
+	// The second request completes first, so requests run concurrently.
+	first, second := client.recv(), client.recv()
+	if bytes.Contains(first, []byte(`"id":1`)) {
+		first, second = second, first
+	}
+	assertResponses(t, first, `{"jsonrpc":"2.0","result":true,"id":2}`)
+	assertResponses(t, second, `{"jsonrpc":"2.0","result":"released","id":1}`)
+}
+
+func TestServeStreamShutdown(t *testing.T) {
+	t.Run("EOF waits for in-flight requests", func(t *testing.T) {
+		server := newTestServer()
+		RegisterMethod(server, "slow", func(ctx context.Context, p EmptyParams) (string, error) {
+			select {
+			case <-time.After(50 * time.Millisecond):
+				return "done", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		})
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+
+		client.send(`{"jsonrpc":"2.0","method":"slow","id":1}`)
+		if err := client.close(); err != nil {
+			t.Fatalf("ServeStream = %v, want nil", err)
+		}
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":"done","id":1}`)
+	})
+
+	t.Run("context cancels in-flight requests", func(t *testing.T) {
+		server := newTestServer()
+		started := make(chan struct{})
+		cancelled := make(chan struct{})
+		RegisterMethod(server, "block", func(ctx context.Context, p EmptyParams) (bool, error) {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			return false, ctx.Err()
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		client := newStreamClient(t, ctx, server, FramingNewline)
+
+		client.send(`{"jsonrpc":"2.0","method":"block","id":1}`)
+		<-started
+		cancel()
+
+		select {
+		case err := <-client.done:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("ServeStream = %v, want context.Canceled", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ServeStream did not return")
+		}
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatal("in-flight request not cancelled")
+		}
+	})
+
+	t.Run("message too large", func(t *testing.T) {
+		server := newTestServer()
+		server.SetMaxBodyBytes(16)
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+
+		client.send(`{"jsonrpc":"2.0","method":"echo","params":"` + strings.Repeat("a", 32) + `","id":1}`)
+		select {
+		case err := <-client.done:
+			if !errors.Is(err, ErrMessageTooLarge) {
+				t.Errorf("ServeStream = %v, want ErrMessageTooLarge", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ServeStream did not return")
+		}
+	})
+}
+
+func TestFrameReader(t *testing.T) {
+	tests := []struct {
+		name     string
+		framing  Framing
+		input    string
+		maxBytes int64
+		want     []string
+		wantErr  error // error after the messages; nil means io.EOF
+	}{
+		{
+			name:    "newline",
+			framing: FramingNewline,
+			input:   "{\"a\":1}\n\n  \r\n{\"b\":2}\r\n{\"c\":3}",
+			want:    []string{`{"a":1}`, `{"b":2}`, `{"c":3}`},
+		},
+		{
+			name:     "newline too large",
+			framing:  FramingNewline,
+			input:    "{\"a\":1}\n" + `{"b":"` + strings.Repeat("b", 64) + `"}` + "\n",
+			maxBytes: 16,
+			want:     []string{`{"a":1}`},
+			wantErr:  ErrMessageTooLarge,
+		},
+		{
+			name:    "content length",
+			framing: FramingContentLength,
+			input:   "Content-Length: 7\r\n\r\n{\"a\":1}Content-Type: application/vscode-jsonrpc; charset=utf-8\r\nContent-Length: 8\r\n\r\n{\"b\":2}\n",
+			want:    []string{`{"a":1}`, "{\"b\":2}\n"},
+		},
+		{
+			name:    "missing content length",
+			framing: FramingContentLength,
+			input:   "Content-Type: application/json\r\n\r\n{}",
+			wantErr: errors.New(`autorpc: invalid Content-Length header ""`),
+		},
+		{
+			name:    "negative content length",
+			framing: FramingContentLength,
+			input:   "Content-Length: -1\r\n\r\n{}",
+			wantErr: errors.New(`autorpc: invalid Content-Length header "-1"`),
+		},
+		{
+			name:    "truncated body",
+			framing: FramingContentLength,
+			input:   "Content-Length: 10\r\n\r\n{}",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:     "content length too large",
+			framing:  FramingContentLength,
+			input:    "Content-Length: 17\r\n\r\n",
+			maxBytes: 16,
+			wantErr:  ErrMessageTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			read := newFrameReader(strings.NewReader(tt.input), tt.framing, tt.maxBytes)
+			for _, want := range tt.want {
+				data, err := read()
+				if err != nil {
+					t.Fatalf("read: %v, want %q", err, want)
+				}
+				if string(data) != want {
+					t.Errorf("read %q, want %q", data, want)
+				}
+			}
+
+			_, err := read()
+			wantErr := tt.wantErr
+			if wantErr == nil {
+				wantErr = io.EOF
+			}
+			if !errors.Is(err, wantErr) && (err == nil || err.Error() != wantErr.Error()) {
+				t.Errorf("error = %v, want %v", err, wantErr)
+			}
+		})
+	}
+}
+
+func TestFrameWriter(t *testing.T) {
+	for _, framing := range []Framing{FramingNewline, FramingContentLength} {
+		var buf bytes.Buffer
+		write := newFrameWriter(&buf, framing)
+		messages := []string{`{"a":1}`, `{"b":"é"}`}
+		for _, message := range messages {
+			if err := write([]byte(message)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		read := newFrameReader(&buf, framing, 0)
+		for _, want := range messages {
+			if data, err := read(); err != nil || string(data) != want {
+				t.Errorf("framing %v: read %q, %v, want %q", framing, data, err, want)
+			}
+		}
+		if _, err := read(); err != io.EOF {
+			t.Errorf("framing %v: error = %v, want io.EOF", framing, err)
+		}
+	}
+}
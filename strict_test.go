@@ -0,0 +1,131 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type strictItem struct {
+	Value int `json:"value"`
+}
+
+type strictParams struct {
+	UserID int          `json:"userId"`
+	Items  []strictItem `json:"items"`
+	Limit  int          `json:"limit" default:"10"`
+	Extra  interface{}  `json:"extra"`
+}
+
+func TestStrictParams(t *testing.T) {
+	echo := func(ctx context.Context, p strictParams) (strictParams, error) {
+		return p, nil
+	}
+	newServer := func(strict bool) *Server {
+		server := NewServer()
+		server.SetStrictParams(strict)
+		RegisterMethod(server, "echo", echo)
+		RegisterMethodWithOptions(server, "loose", echo, WithStrictParams(false))
+		RegisterMethodWithOptions(server, "strict", echo, WithStrictParams(true))
+		return server
+	}
+
+	tests := []struct {
+		name     string
+		strict   bool // server setting
+		method   string
+		params   string
+		want     strictParams
+		wantPath string // path of the rejected field; empty if the call succeeds
+	}{
+		{name: "exact names", strict: true, method: "echo", params: `{"userId":1,"items":[{"value":2}]}`, want: strictParams{UserID: 1, Items: []strictItem{{Value: 2}}, Limit: 10}},
+		{name: "case mismatch rejected", strict: true, method: "echo", params: `{"userid":1}`, wantPath: "userid"},
+		{name: "unknown field rejected", strict: true, method: "echo", params: `{"userId":1,"admin":true}`, wantPath: "admin"},
+		{name: "nested unknown field", strict: true, method: "echo", params: `{"userId":1,"items":[{"x":1}]}`, wantPath: "items[0].x"},
+		{name: "nested case mismatch", strict: true, method: "echo", params: `{"items":[{"value":1},{"Value":2}]}`, wantPath: "items[1].Value"},
+		{name: "interface fields accept anything", strict: true, method: "echo", params: `{"extra":{"any":"key"}}`, want: strictParams{Limit: 10, Extra: map[string]interface{}{"any": "key"}}},
+		{name: "not strict", strict: false, method: "echo", params: `{"userid":1,"admin":true}`, want: strictParams{UserID: 1, Limit: 10}},
+		{name: "method override disables", strict: true, method: "loose", params: `{"userid":1}`, want: strictParams{UserID: 1, Limit: 10}},
+		{name: "method override enables", strict: false, method: "strict", params: `{"userid":1}`, wantPath: "userid"},
+
+		{name: "positional", strict: true, method: "echo", params: `[1, [{"value":2}], 3]`, want: strictParams{UserID: 1, Items: []strictItem{{Value: 2}}, Limit: 3}},
+		{name: "positional nested unknown field", strict: true, method: "echo", params: `[1, [{"x":2}]]`, wantPath: "items[0].x"},
+
+		{name: "default applied", strict: true, method: "echo", params: `{"userId":1}`, want: strictParams{UserID: 1, Limit: 10}},
+		{name: "default overridden", strict: true, method: "echo", params: `{"userId":1,"limit":0}`, want: strictParams{UserID: 1, Limit: 0}},
+		{name: "default with positional", strict: true, method: "echo", params: `[1]`, want: strictParams{UserID: 1, Limit: 10}},
+		{name: "default with rejected field", strict: true, method: "echo", params: `{"Limit":5}`, wantPath: "Limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callJSON(newServer(tt.strict), tt.method, tt.params)
+			if tt.wantPath != "" {
+				if resp.Error == nil || resp.Error.Code != CodeInvalidParams {
+					t.Fatalf("response = %+v, want invalid params", resp)
+				}
+				data, _ := resp.Error.Data.(map[string]any)
+				if data["path"] != tt.wantPath {
+					t.Errorf("path = %v, want %q", data["path"], tt.wantPath)
+				}
+				return
+			}
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %+v", resp.Error)
+			}
+			if got := resp.Result.(strictParams); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("params = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	anything := func(ctx context.Context, p interface{}) (interface{}, error) {
+		return p, nil
+	}
+	extra := func(ctx context.Context, p strictParams) (interface{}, error) {
+		return p.Extra, nil
+	}
+	newServer := func(useNumber bool) *Server {
+		server := NewServer()
+		server.SetUseNumber(useNumber)
+		RegisterMethod(server, "any", anything)
+		RegisterMethodWithOptions(server, "float", anything, WithUseNumber(false))
+		RegisterMethodWithOptions(server, "number", anything, WithUseNumber(true))
+		RegisterMethod(server, "extra", extra)
+		RegisterMethodWithOptions(server, "strict", extra, WithStrictParams(true))
+		return server
+	}
+
+	const big = "12345678901234567890"
+	tests := []struct {
+		name      string
+		useNumber bool // server setting
+		method    string
+		params    string
+		want      interface{}
+	}{
+		{name: "interface params", useNumber: true, method: "any", params: `{"id":` + big + `}`, want: map[string]interface{}{"id": json.Number(big)}},
+		{name: "nested values", useNumber: true, method: "any", params: `[[1.5]]`, want: []interface{}{[]interface{}{json.Number("1.5")}}},
+		{name: "disabled", useNumber: false, method: "any", params: `{"id":1}`, want: map[string]interface{}{"id": float64(1)}},
+		{name: "method override disables", useNumber: true, method: "float", params: `{"id":1}`, want: map[string]interface{}{"id": float64(1)}},
+		{name: "method override enables", useNumber: false, method: "number", params: `{"id":1}`, want: map[string]interface{}{"id": json.Number("1")}},
+		{name: "interface field", useNumber: true, method: "extra", params: `{"extra":` + big + `}`, want: json.Number(big)},
+		{name: "interface field with strict", useNumber: true, method: "strict", params: `{"extra":{"n":2}}`, want: map[string]interface{}{"n": json.Number("2")}},
+		{name: "interface field by position", useNumber: true, method: "extra", params: `[1, [], 10, ` + big + `]`, want: json.Number(big)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := callJSON(newServer(tt.useNumber), tt.method, tt.params)
+			if resp.Error != nil {
+				t.Fatalf("unexpected error: %+v", resp.Error)
+			}
+			if !reflect.DeepEqual(resp.Result, tt.want) {
+				t.Errorf("result = %#v, want %#v", resp.Result, tt.want)
+			}
+		})
+	}
+}
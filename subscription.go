@@ -0,0 +1,110 @@
+package autorpc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// SubscriptionEvent is sent as the params of the notifications streamed for a subscription.
+// The notification method is the name of the subscription method.
+type SubscriptionEvent struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+// RegisterSubscription registers a subscription method with the given name.
+// The function is called when a client subscribes and returns a channel of events.
+// The client receives a subscription id as the result, followed by one notification per event
+// with the subscription method name and a SubscriptionEvent as params.
+//
+// Events are streamed until the channel is closed, the client calls the "<name>.unsubscribe"
+// method (also registered) with the subscription id, or the connection is closed.
+// In all cases the context passed to fn is cancelled, so producers should stop sending when it is done.
+//
+// Subscriptions are only available on persistent transports such as WebSocketHandler.
+//
+// Example:
+//
+//	autorpc.RegisterSubscription(server, "prices", func(ctx context.Context, symbol string) (<-chan Price, error) {
+//	    ch := make(chan Price)
+//	    go feed.Watch(ctx, symbol, ch)
+//	    return ch, nil
+//	})
+func RegisterSubscription[P, E any](
+	r Registerer,
+	name string,
+	fn func(context.Context, P) (<-chan E, error),
+	opts ...MethodOption,
+) {
+	subscribe := func(ctx context.Context, params P) (string, error) {
+		sess, ok := notifierFromContext(ctx).(*session)
+		if !ok {
+			return "", NewError(CodeInvalidRequest, "Subscriptions require a persistent connection", nil)
+		}
+
+		// The subscription outlives the request, but keeps its values (transport, middleware data).
+		subCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		stop := context.AfterFunc(sess.ctx, cancel)
+
+		events, err := fn(subCtx, params)
+		if err != nil {
+			stop()
+			cancel()
+			return "", err
+		}
+
+		method := name
+		if req, ok := rpcRequestFromContext(ctx); ok {
+			method = req.Method
+		}
+
+		id := sess.addSubscription(cancel)
+		afterResponse(ctx, func() {
+			go func() {
+				defer stop()
+				defer sess.removeSubscription(id)
+
+				for {
+					select {
+					case <-subCtx.Done():
+						return
+					case event, ok := <-events:
+						if !ok {
+							return
+						}
+						if err := sess.notify(method, SubscriptionEvent{Subscription: id, Result: event}); err != nil {
+							return
+						}
+					}
+				}
+			}()
+		})
+
+		return id, nil
+	}
+
+	unsubscribe := func(ctx context.Context, id string) (bool, error) {
+		sess, ok := notifierFromContext(ctx).(*session)
+		if !ok {
+			return false, NewError(CodeInvalidRequest, "Subscriptions require a persistent connection", nil)
+		}
+		return sess.removeSubscription(id), nil
+	}
+
+	// The unsubscribe method shares the middlewares of the subscription, but not its documentation.
+	unsubscribeOpts := []MethodOption{WithDescription("Cancels a subscription created by " + name + ".")}
+	for _, mw := range newMethodOptions(opts...).middlewares.middlewares {
+		unsubscribeOpts = append(unsubscribeOpts, mw)
+	}
+
+	RegisterMethodWithOptions(r, name, subscribe, opts...)
+	RegisterMethodWithOptions(r, name+".unsubscribe", unsubscribe, unsubscribeOpts...)
+}
+
+// newRandomID returns a random hex identifier, used for subscriptions and jobs.
+func newRandomID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
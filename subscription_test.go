@@ -0,0 +1,115 @@
+package autorpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// subscribe sends a subscription request and returns the subscription id.
+func (c *streamClient) subscribe(method string, id int) string {
+	c.t.Helper()
+	c.send(`{"jsonrpc":"2.0","method":"` + method + `","id":` + strconv.Itoa(id) + `}`)
+	var resp RPCResponse
+	if err := json.Unmarshal(c.recv(), &resp); err != nil || resp.Error != nil {
+		c.t.Fatalf("subscribe failed: %v %+v", err, resp.Error)
+	}
+	return resp.Result.(string)
+}
+
+func waitDone(t *testing.T, ctx context.Context, what string) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s: subscription context not cancelled", what)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	server := newTestServer()
+	events := make(chan int)
+	contexts := make(chan context.Context, 1)
+	RegisterSubscription(server, "ticks", func(ctx context.Context, p EmptyParams) (<-chan int, error) {
+		contexts <- ctx
+		return events, nil
+	})
+	RegisterSubscription(server, "broken", func(ctx context.Context, p EmptyParams) (<-chan int, error) {
+		return nil, NewError(1000, "unavailable", nil)
+	})
+
+	t.Run("events and unsubscribe", func(t *testing.T) {
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+		id := client.subscribe("ticks", 1)
+		subCtx := <-contexts
+
+		for i := 1; i <= 2; i++ {
+			events <- i
+			want, _ := json.Marshal(RPCNotification{JSONRPC: "2.0", Method: "ticks", Params: SubscriptionEvent{Subscription: id, Result: i}})
+			assertResponses(t, client.recv(), string(want))
+		}
+
+		client.send(`{"jsonrpc":"2.0","method":"ticks.unsubscribe","params":"` + id + `","id":2}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":true,"id":2}`)
+		waitDone(t, subCtx, "unsubscribe")
+
+		client.send(`{"jsonrpc":"2.0","method":"ticks.unsubscribe","params":"` + id + `","id":3}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":false,"id":3}`)
+	})
+
+	t.Run("connection closed", func(t *testing.T) {
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+		client.subscribe("ticks", 1)
+		subCtx := <-contexts
+
+		if err := client.close(); err != nil {
+			t.Fatalf("ServeStream = %v, want nil", err)
+		}
+		waitDone(t, subCtx, "connection closed")
+	})
+
+	t.Run("subscribe error", func(t *testing.T) {
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+		client.send(`{"jsonrpc":"2.0","method":"broken","id":1}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","error":{"code":1000},"id":1}`)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		rec := postHTTP(HTTPHandler(server), `{"jsonrpc":"2.0","method":"ticks","id":1}`, nil)
+		assertResponses(t, rec.Body.Bytes(), `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`)
+		rec = postHTTP(HTTPHandler(server), `{"jsonrpc":"2.0","method":"ticks.unsubscribe","params":"x","id":1}`, nil)
+		assertResponses(t, rec.Body.Bytes(), `{"jsonrpc":"2.0","error":{"code":-32600},"id":1}`)
+	})
+}
+
+func TestNotify(t *testing.T) {
+	server := newTestServer()
+	RegisterMethod(server, "buy", func(ctx context.Context, symbol string) (bool, error) {
+		if err := Notify(ctx, "price.updated", map[string]interface{}{"symbol": symbol, "value": 42}); err != nil {
+			if errors.Is(err, ErrNotificationsNotSupported) {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		client := newStreamClient(t, context.Background(), server, FramingNewline)
+		client.send(`{"jsonrpc":"2.0","method":"buy","params":"ABC","id":1}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","method":"price.updated","params":{"symbol":"ABC","value":42}}`)
+		assertResponses(t, client.recv(), `{"jsonrpc":"2.0","result":true,"id":1}`)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		rec := postHTTP(HTTPHandler(server), `{"jsonrpc":"2.0","method":"buy","params":"ABC","id":1}`, nil)
+		if rec.Code != http.StatusOK {
+			t.Errorf("status = %d, want 200", rec.Code)
+		}
+		assertResponses(t, rec.Body.Bytes(), `{"jsonrpc":"2.0","result":false,"id":1}`)
+	})
+}
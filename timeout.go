@@ -0,0 +1,60 @@
+package autorpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeoutHeader is the HTTP header a client can send to limit the time the server spends on a request.
+// Its value is a Go duration ("1.5s", "200ms") or a number of milliseconds ("1500").
+// It can only shorten the timeout of a method, never extend it.
+const TimeoutHeader = "X-RPC-Timeout"
+
+// SetDefaultTimeout sets the timeout of methods registered without WithTimeout.
+// Zero, the default, means no timeout.
+func (s *Server) SetDefaultTimeout(timeout time.Duration) {
+	s.defaultTimeout = timeout
+}
+
+// WithTimeout sets the maximum duration of a call to the method, including its middlewares.
+// When it elapses, the context of the handler is cancelled and the client receives a
+// CodeRequestTimeout error, even if the handler ignores its context.
+// Zero disables the server default timeout for the method.
+func WithTimeout(timeout time.Duration) MethodOption {
+	return methodOptionFunc(func(o *methodOptions) {
+		o.timeout = &timeout
+	})
+}
+
+// methodTimeout returns the timeout of handler, or 0 if it has none.
+func (s *Server) methodTimeout(handler *methodHandler) time.Duration {
+	if handler.timeout != nil {
+		return *handler.timeout
+	}
+	return s.defaultTimeout
+}
+
+// withTimeoutHeader applies the TimeoutHeader of r to ctx. Invalid values are ignored.
+func withTimeoutHeader(ctx context.Context, r *http.Request) (context.Context, context.CancelFunc) {
+	timeout, ok := parseTimeout(r.Header.Get(TimeoutHeader))
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// parseTimeout parses a TimeoutHeader value.
+func parseTimeout(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+	timeout, err := time.ParseDuration(value)
+	return timeout, err == nil && timeout > 0
+}
@@ -0,0 +1,89 @@
+package autorpc
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "1500", want: 1500 * time.Millisecond, wantOK: true},
+		{value: " 200ms ", want: 200 * time.Millisecond, wantOK: true},
+		{value: "1.5s", want: 1500 * time.Millisecond, wantOK: true},
+		{value: ""},
+		{value: "0"},
+		{value: "-5"},
+		{value: "-1s"},
+		{value: "soon"},
+	}
+	for _, tt := range tests {
+		got, ok := parseTimeout(tt.value)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("parseTimeout(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMethodTimeout(t *testing.T) {
+	server := NewServer()
+	server.SetDefaultTimeout(20 * time.Millisecond)
+
+	// slow ignores its context, so only the server can end the call early.
+	slow := func(ctx context.Context, p EmptyParams) (bool, error) {
+		time.Sleep(200 * time.Millisecond)
+		return true, nil
+	}
+	RegisterMethod(server, "default", slow)
+	RegisterMethodWithOptions(server, "short", slow, WithTimeout(10*time.Millisecond))
+	RegisterMethodWithOptions(server, "none", slow, WithTimeout(0))
+	RegisterMethodWithOptions(server, "deadline", func(ctx context.Context, p EmptyParams) (bool, error) {
+		_, ok := ctx.Deadline()
+		return ok, nil
+	}, WithTimeout(time.Second))
+	handler := HTTPHandler(server)
+
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+		want   string
+	}{
+		{name: "server default", method: "default", want: `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`},
+		{name: "method timeout", method: "short", want: `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`},
+		{name: "disabled", method: "none", want: `{"jsonrpc":"2.0","result":true,"id":1}`},
+		{name: "handler sees deadline", method: "deadline", want: `{"jsonrpc":"2.0","result":true,"id":1}`},
+		{name: "header shortens", method: "none", header: http.Header{TimeoutHeader: {"20ms"}}, want: `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`},
+		{name: "header in milliseconds", method: "none", header: http.Header{TimeoutHeader: {"20"}}, want: `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`},
+		{name: "header cannot extend", method: "short", header: http.Header{TimeoutHeader: {"10s"}}, want: `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`},
+		{name: "invalid header ignored", method: "none", header: http.Header{TimeoutHeader: {"soon"}}, want: `{"jsonrpc":"2.0","result":true,"id":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			rec := postHTTP(handler, `{"jsonrpc":"2.0","method":"`+tt.method+`","id":1}`, tt.header)
+			assertResponses(t, rec.Body.Bytes(), tt.want)
+
+			timedOut := tt.want == `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`
+			if elapsed := time.Since(start); timedOut && elapsed > 150*time.Millisecond {
+				t.Errorf("response after %v, want the timeout", elapsed)
+			}
+		})
+	}
+}
+
+func TestTimeoutHeaderCancelsContext(t *testing.T) {
+	server := NewServer()
+	RegisterMethod(server, "block", func(ctx context.Context, p EmptyParams) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+
+	rec := postHTTP(HTTPHandler(server), `{"jsonrpc":"2.0","method":"block","id":1}`, http.Header{TimeoutHeader: {"20ms"}})
+	assertResponses(t, rec.Body.Bytes(), `{"jsonrpc":"2.0","error":{"code":-32001},"id":1}`)
+}
@@ -19,6 +19,14 @@ type RPCResponse struct {
 	ID      json.RawMessage `json:"id"`
 }
 
+// RPCNotification is a request without an id, sent by the server to the client
+// over persistent transports. The client must not reply to it.
+type RPCNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 type RPCError struct {
 	Code    int         `json:"code"`
 	Message string      `json:"message"`
@@ -31,6 +39,12 @@ const (
 	CodeMethodNotFound = -32601
 	CodeInvalidParams  = -32602
 	CodeInternalError  = -32603
+
+	// CodeRequestTimeout is returned when a call exceeds its timeout (see WithTimeout).
+	CodeRequestTimeout = -32001
+
+	// CodeRequestCancelled is returned when the client cancels a request (see CancelRequestMethod).
+	CodeRequestCancelled = -32800
 )
 
 func newErrorResponse(id json.RawMessage, code int, message string) RPCResponse {
@@ -53,3 +67,9 @@ type FuncType[P, R any] func(ctx context.Context, params P) (R, error)
 type UnmarshalKind interface {
 	UnmarshalKind() string
 }
+
+// JSONSchemaFormat can be implemented by types implementing UnmarshalKind to specify
+// the JSON Schema "format" of their values, such as "date-time" for types.Time.
+type JSONSchemaFormat interface {
+	JSONSchemaFormat() string
+}
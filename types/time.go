@@ -51,3 +51,7 @@ func (t *Time) UnmarshalJSON(b []byte) error {
 func (t Time) UnmarshalKind() string {
 	return "string"
 }
+
+func (t Time) JSONSchemaFormat() string {
+	return "date-time"
+}
@@ -0,0 +1,354 @@
+package autorpc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateTypeScript writes TypeScript definitions for spec to w: an interface (or type alias)
+// for every type in spec.Types, a Methods map describing the params, result and declared errors of every method,
+// and a createClient function returning a typed wrapper per method.
+//
+// The generated client is transport agnostic; httpTransport uses fetch to call an HTTPHandler endpoint:
+//
+//	const api = createClient(httpTransport("/rpc"));
+//	const sum = await api["math.add"]({ a: 1, b: 2 });
+func GenerateTypeScript(spec ServerSpec, w io.Writer) error {
+	g := &tsGenerator{
+		spec:  spec,
+		names: tsTypeNames(spec.Types),
+		w:     bufio.NewWriter(w),
+	}
+	g.generate()
+	return g.w.Flush()
+}
+
+type tsGenerator struct {
+	spec  ServerSpec
+	names map[string]string // qualified Go type name -> TypeScript name
+	w     *bufio.Writer
+}
+
+func (g *tsGenerator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.w, format, args...)
+}
+
+func (g *tsGenerator) generate() {
+	g.printf("// Code generated by autorpc. DO NOT EDIT.\n\n")
+
+	typeNames := make([]string, 0, len(g.spec.Types))
+	for name := range g.spec.Types {
+		if _, ok := tsBuiltinTypes[name]; !ok {
+			typeNames = append(typeNames, name)
+		}
+	}
+	sort.Slice(typeNames, func(i, j int) bool {
+		return g.names[typeNames[i]] < g.names[typeNames[j]]
+	})
+	for _, name := range typeNames {
+		g.generateType(name, g.spec.Types[name])
+	}
+
+	methods := make([]MethodInfo, len(g.spec.Methods))
+	copy(methods, g.spec.Methods)
+	sort.Slice(methods, func(i, j int) bool {
+		return methods[i].Name < methods[j].Name
+	})
+
+	g.printf("export interface Methods {\n")
+	for _, method := range methods {
+		g.printDoc("  ", method.Description, method.Deprecated, method.DeprecationReason)
+		g.printf("  %s: { params: %s; result: %s; errors: %s };\n",
+			strconv.Quote(method.Name), g.typeExpr(method.Params, ""), g.typeExpr(method.Result, ""), g.errorsExpr(method.Errors))
+	}
+	g.printf("}\n\n")
+
+	g.printf("%s\n", tsRuntime)
+
+	g.printf("export function createClient(call: CallFn) {\n")
+	g.printf("  return {\n")
+	for _, method := range methods {
+		g.printDoc("    ", method.Description, method.Deprecated, method.DeprecationReason)
+		g.printf("    %s: (params: Methods[%s][\"params\"]) => call(%s, params),\n",
+			strconv.Quote(method.Name), strconv.Quote(method.Name), strconv.Quote(method.Name))
+	}
+	g.printf("  };\n")
+	g.printf("}\n")
+}
+
+func (g *tsGenerator) generateType(qualifiedName string, info TypeInfo) {
+	tsName := g.names[qualifiedName]
+
+	if info.Kind != "struct" {
+		g.printf("export type %s = %s;\n\n", tsName, tsPrimitive(info.Kind))
+		return
+	}
+
+	g.printf("export interface %s {\n", tsName)
+	for _, field := range info.Fields {
+		name := field.JSONName
+		if name == "" {
+			name = field.Name
+		}
+		if !isTSIdentifier(name) {
+			name = strconv.Quote(name)
+		}
+
+		typ := g.fieldTypeExpr(field)
+		doc := field.Description
+		if len(field.Default) > 0 {
+			doc = strings.TrimSpace(doc + "\n@default " + string(field.Default))
+		}
+		g.printDoc("  ", doc, false, "")
+		if field.IsPointer {
+			g.printf("  %s?: %s | null;\n", name, typ)
+		} else if len(field.Default) > 0 {
+			g.printf("  %s?: %s;\n", name, typ)
+		} else {
+			g.printf("  %s: %s;\n", name, typ)
+		}
+	}
+	g.printf("}\n\n")
+}
+
+// errorsExpr returns the union of the error types declared by a method, or never.
+func (g *tsGenerator) errorsExpr(errors []ErrorInfo) string {
+	if len(errors) == 0 {
+		return "never"
+	}
+
+	variants := make([]string, 0, len(errors))
+	for _, e := range errors {
+		data := "undefined"
+		if e.DataType != "" {
+			data = g.typeExpr(e.DataType, "")
+		}
+		variants = append(variants, fmt.Sprintf("{ code: %d; message: %s; data: %s }", e.Code, strconv.Quote(e.Message), data))
+	}
+	return strings.Join(variants, " | ")
+}
+
+// printDoc prints a JSDoc comment, if there is anything to document.
+func (g *tsGenerator) printDoc(indent, description string, deprecated bool, deprecationReason string) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if deprecated {
+		lines = append(lines, strings.TrimSpace("@deprecated "+deprecationReason))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	if len(lines) == 1 {
+		g.printf("%s/** %s */\n", indent, escapeJSDoc(lines[0]))
+		return
+	}
+	g.printf("%s/**\n", indent)
+	for _, line := range lines {
+		g.printf("%s * %s\n", indent, escapeJSDoc(line))
+	}
+	g.printf("%s */\n", indent)
+}
+
+func escapeJSDoc(s string) string {
+	return strings.ReplaceAll(s, "*/", "*\\/")
+}
+
+func (g *tsGenerator) fieldTypeExpr(field FieldInfo) string {
+	if field.ArrayDepth > 0 && field.ElementType != "" {
+		return g.typeExpr("[]"+field.ElementType, field.Kind)
+	}
+	return g.typeExpr(field.Type, field.Kind)
+}
+
+// typeExpr converts a Go type name as found in the spec ("[]*pkg.Type", "map[string]int", ...)
+// to a TypeScript type expression. fallbackKind is used for named types that are not in spec.Types.
+func (g *tsGenerator) typeExpr(goType string, fallbackKind string) string {
+	switch {
+	case goType == "[]uint8":
+		// encoding/json sends byte slices as base64 strings
+		return "string"
+	case strings.HasPrefix(goType, "*"):
+		return g.typeExpr(strings.TrimLeft(goType, "*"), fallbackKind) + " | null"
+	case strings.HasPrefix(goType, "[]"):
+		elem := g.typeExpr(goType[2:], fallbackKind)
+		if hasTopLevelUnion(elem) {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case strings.HasPrefix(goType, "map["):
+		end := matchingBracket(goType, len("map"))
+		if end < 0 {
+			return "unknown"
+		}
+		value := g.typeExpr(goType[end+1:], "")
+		return "Record<string, " + value + ">"
+	}
+
+	if builtin, ok := tsBuiltinTypes[goType]; ok {
+		return builtin
+	}
+	if name, ok := g.names[goType]; ok {
+		return name
+	}
+	if primitive := tsPrimitive(goType); primitive != "unknown" {
+		return primitive
+	}
+	return tsPrimitive(fallbackKind)
+}
+
+// hasTopLevelUnion reports whether expr is a union type that needs parentheses to be used as an array element.
+func hasTopLevelUnion(expr string) bool {
+	depth := 0
+	for _, r := range expr {
+		switch r {
+		case '(', '<':
+			depth++
+		case ')', '>':
+			depth--
+		case '|':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchingBracket returns the index of the "]" matching the "[" at start.
+func matchingBracket(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// tsBuiltinTypes maps named Go types with their own JSON encoding to the TypeScript type of their encoded value.
+// They are not generated as interfaces.
+var tsBuiltinTypes = map[string]string{
+	"time.Time": "string", // RFC 3339
+}
+
+func tsPrimitive(kind string) string {
+	switch kind {
+	case "bool", "boolean":
+		return "boolean"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "number", "integer":
+		return "number"
+	case "string":
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// tsTypeNames assigns a TypeScript name to every type of the spec. The Go type name is used
+// unless several packages define it, in which case the package name is prepended.
+func tsTypeNames(types map[string]TypeInfo) map[string]string {
+	count := make(map[string]int)
+	for qualifiedName, info := range types {
+		if _, ok := tsBuiltinTypes[qualifiedName]; !ok {
+			count[tsIdentifier(info.Name)]++
+		}
+	}
+
+	names := make(map[string]string, len(types))
+	for qualifiedName, info := range types {
+		if _, ok := tsBuiltinTypes[qualifiedName]; ok {
+			continue
+		}
+		name := tsIdentifier(info.Name)
+		if count[name] > 1 && info.Package != "" {
+			name = tsIdentifier(path.Base(info.Package)) + name
+		}
+		names[qualifiedName] = name
+	}
+	return names
+}
+
+// tsIdentifier converts s to a valid, exported-looking TypeScript identifier.
+func tsIdentifier(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isTSIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r == '$' || unicode.IsLetter(r) || (i > 0 && unicode.IsDigit(r)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+const tsRuntime = `export type MethodName = keyof Methods;
+
+export type CallFn = <M extends MethodName>(
+  method: M,
+  params: Methods[M]["params"],
+) => Promise<Methods[M]["result"]>;
+
+export type MethodError<M extends MethodName> = Methods[M]["errors"];
+
+export class RPCError extends Error {
+  constructor(
+    public code: number,
+    message: string,
+    public data?: unknown,
+  ) {
+    super(message);
+    this.name = "RPCError";
+  }
+}
+
+export function httpTransport(url: string, init: RequestInit = {}): CallFn {
+  let nextId = 0;
+  return async (method, params) => {
+    const res = await fetch(url, {
+      ...init,
+      method: "POST",
+      headers: { "Content-Type": "application/json", ...init.headers },
+      body: JSON.stringify({ jsonrpc: "2.0", method, params, id: ++nextId }),
+    });
+    const body = await res.json();
+    if (body.error) {
+      throw new RPCError(body.error.code, body.error.message, body.error.data);
+    }
+    return body.result;
+  };
+}
+`
@@ -0,0 +1,73 @@
+package autorpc
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+type tsItem struct {
+	Name string `json:"name" desc:"Item name"`
+}
+
+type tsParams struct {
+	ID       int                 `json:"id"`
+	Parent   *tsItem             `json:"parent"`
+	Items    []*tsItem           `json:"items"`
+	Matrix   [][]float64         `json:"matrix"`
+	Labels   map[string][]string `json:"labels"`
+	Data     []byte              `json:"data"`
+	Chunks   [][]byte            `json:"chunks"`
+	Created  time.Time           `json:"created"`
+	Updated  *time.Time          `json:"updated"`
+	History  []time.Time         `json:"history"`
+	Limit    int                 `json:"limit" default:"10"`
+	Any      interface{}         `json:"any"`
+	OddName  string              `json:"odd-name"`
+	internal string
+}
+
+type tsErrorData struct {
+	Field string `json:"field"`
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	server := NewServer()
+	RegisterMethodWithOptions(server, "items.update", func(ctx context.Context, p tsParams) ([]tsItem, error) {
+		return nil, nil
+	},
+		WithDescription("Updates items"),
+		WithErrors(NewError(1001, "not found", nil), NewError(1002, "invalid", tsErrorData{})),
+	)
+	RegisterMethodWithOptions(server, "items.raw", func(ctx context.Context, p []byte) (time.Time, error) {
+		return time.Time{}, nil
+	}, WithDeprecated("use items.update"))
+	RegisterMethod(server, "items.count", func(ctx context.Context, p map[string]*int) (int, error) {
+		return 0, nil
+	})
+
+	var buf bytes.Buffer
+	if err := GenerateTypeScript(server.GetMethodSpecs(), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "typescript.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("generated TypeScript does not match %s (run go test -update to regenerate):\n%s", golden, buf.Bytes())
+	}
+}
@@ -1,11 +1,39 @@
 package autorpc
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
+// Validator validates decoded struct params. *validator.Validate implements it,
+// and other validation libraries can be plugged in with a small adapter.
+//
+// If Struct returns validator.ValidationErrors, they are passed to the ValidateErrorHandler.
+// Errors implementing RPCErrorProvider are returned as is, and any other error is returned
+// as CodeInvalidParams with the error text as data.
+type Validator interface {
+	Struct(s interface{}) error
+}
+
+// NewValidator returns the validator used by default, which reports fields by their JSON name.
+// Use it as a base to register custom validations:
+//
+//	v := autorpc.NewValidator()
+//	v.RegisterValidation("iban", validateIBAN)
+//	server.SetValidator(v)
+func NewValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _ := jsonFieldName(field)
+		return name
+	})
+	return validate
+}
+
 type ValidateErrorHandler func(*validator.ValidationErrors) *RPCError
 
 // Returns CodeInvalidParams with validation error details
@@ -14,7 +42,7 @@ func defaultValidateErrorHandler(errs *validator.ValidationErrors) *RPCError {
 	for _, err := range *errs {
 		details := map[string]any{}
 		if err.Field() != "" {
-			details["field"] = err.Namespace()
+			details["field"] = validationFieldPath(err)
 		}
 		if err.Tag() != "" {
 			details["tag"] = err.Tag()
@@ -22,6 +50,7 @@ func defaultValidateErrorHandler(errs *validator.ValidationErrors) *RPCError {
 		if err.Value() != nil {
 			details["value"] = err.Value()
 		}
+		details["message"] = validationMessage(err)
 		errorDetails = append(errorDetails, details)
 	}
 
@@ -32,20 +61,93 @@ func defaultValidateErrorHandler(errs *validator.ValidationErrors) *RPCError {
 	}
 }
 
-func validateParams(params interface{}, handler ValidateErrorHandler) *RPCError {
-	if reflect.TypeOf(params).Kind() != reflect.Struct {
+// validationFieldPath returns the path of the field in the params, without the name
+// of the params struct: "items[0].name" rather than "OrderParams.items[0].name".
+func validationFieldPath(err validator.FieldError) string {
+	namespace := err.Namespace()
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// validationMessage returns an English message describing a failed validation rule.
+// Translated messages can be produced by a custom ValidateErrorHandler using FieldError.Translate.
+func validationMessage(err validator.FieldError) string {
+	field := validationFieldPath(err)
+	param := err.Param()
+
+	switch err.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return field + " is required"
+	case "min", "gte":
+		return fmt.Sprintf("%s must be at least %s%s", field, param, lengthUnit(err.Kind()))
+	case "max", "lte":
+		return fmt.Sprintf("%s must be at most %s%s", field, param, lengthUnit(err.Kind()))
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s%s", field, param, lengthUnit(err.Kind()))
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", field, param)
+	case "eq":
+		return fmt.Sprintf("%s must be equal to %s", field, param)
+	case "ne":
+		return fmt.Sprintf("%s must not be equal to %s", field, param)
+	case "oneof":
+		return fmt.Sprintf("%s must be one of [%s]", field, param)
+	case "email":
+		return field + " must be a valid email address"
+	case "url", "http_url":
+		return field + " must be a valid URL"
+	case "uuid", "uuid4":
+		return field + " must be a valid UUID"
+	}
+
+	if param != "" {
+		return fmt.Sprintf("%s failed the %q validation (%s)", field, err.Tag(), param)
+	}
+	return fmt.Sprintf("%s failed the %q validation", field, err.Tag())
+}
+
+// lengthUnit returns the unit of the length checked by min, max and len for a value of kind k,
+// or "" for numbers, whose value is checked.
+func lengthUnit(k reflect.Kind) string {
+	switch k {
+	case reflect.String:
+		return " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items long"
+	}
+	return ""
+}
+
+// validateParams validates struct params with validate.
+func validateParams(validate Validator, params interface{}, handler ValidateErrorHandler) *RPCError {
+	err := validate.Struct(params)
+	if err == nil {
 		return nil
 	}
 
-	validate := validator.New()
-	if err := validate.Struct(params); err != nil {
-		if validationErrors, ok := err.(validator.ValidationErrors); ok {
-			return handler(&validationErrors)
-		}
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return handler(&validationErrors)
+	}
+
+	var invalidErr *validator.InvalidValidationError
+	if errors.As(err, &invalidErr) {
 		return &RPCError{
 			Code:    CodeInternalError,
 			Message: "Validation error: " + err.Error(),
 		}
 	}
-	return nil
+
+	if _, ok := err.(RPCErrorProvider); ok {
+		return errorToRPCError(err)
+	}
+	return &RPCError{
+		Code:    CodeInvalidParams,
+		Message: "Invalid params",
+		Data:    err.Error(),
+	}
 }
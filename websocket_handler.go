@@ -0,0 +1,132 @@
+package autorpc
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	websocketWriteWait  = 10 * time.Second
+	websocketPongWait   = 60 * time.Second
+	websocketPingPeriod = (websocketPongWait * 9) / 10
+)
+
+type websocketConnKey struct{}
+
+// WithWebSocketConn returns a copy of ctx carrying conn, as returned by WebSocketConnFromContext.
+// WebSocketHandler calls it for every request; it is exported for custom transports and tests.
+func WithWebSocketConn(ctx context.Context, conn *websocket.Conn) context.Context {
+	return context.WithValue(ctx, websocketConnKey{}, conn)
+}
+
+// WebSocketConnFromContext returns the WebSocket connection the request was received on,
+// or nil if the request did not come from WebSocketHandler.
+func WebSocketConnFromContext(ctx context.Context) *websocket.Conn {
+	if conn, ok := ctx.Value(websocketConnKey{}).(*websocket.Conn); ok {
+		return conn
+	}
+	return nil
+}
+
+// WebSocketHandler returns an http.Handler that upgrades the connection to a WebSocket
+// and keeps it open. Every incoming text or binary frame is treated as a JSON-RPC message
+// (single request or batch) and the response is written back on the same socket.
+// Requests of a connection are processed concurrently, so responses may arrive out of order
+// and must be matched by id.
+//
+// The upgrade request is available to middleware through HTTPRequestFromContext,
+// and the connection itself through WebSocketConnFromContext.
+//
+// The upgrade uses the default origin check, which rejects browsers on another origin
+// with 403. Use WebSocketHandlerWithOptions to allow them.
+func WebSocketHandler(server *Server) http.Handler {
+	return WebSocketHandlerWithOptions(server, WebSocketOptions{})
+}
+
+// WebSocketOptions configures WebSocketHandlerWithOptions.
+type WebSocketOptions struct {
+	// Upgrader is used to upgrade connections. Nil means a zero websocket.Upgrader.
+	Upgrader *websocket.Upgrader
+
+	// CheckOrigin, if set, replaces the CheckOrigin function of the upgrader.
+	// For example, to accept a dashboard served from another origin:
+	//
+	//	CheckOrigin: func(r *http.Request) bool {
+	//	    return r.Header.Get("Origin") == "https://dashboard.example.com"
+	//	}
+	CheckOrigin func(r *http.Request) bool
+}
+
+// WebSocketHandlerWithOptions is like WebSocketHandler, with a configurable upgrade.
+func WebSocketHandlerWithOptions(server *Server, opts WebSocketOptions) http.Handler {
+	var upgrader websocket.Upgrader
+	if opts.Upgrader != nil {
+		upgrader = *opts.Upgrader
+	}
+	if opts.CheckOrigin != nil {
+		upgrader.CheckOrigin = opts.CheckOrigin
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade already replied with an HTTP error
+			return
+		}
+		defer conn.Close()
+		if server.maxBodyBytes > 0 {
+			conn.SetReadLimit(server.maxBodyBytes)
+		}
+
+		ctx := WithHTTPRequest(r.Context(), r)
+		ctx = WithWebSocketConn(ctx, conn)
+
+		sess := newSession(ctx, server, func(data []byte) error {
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			return conn.WriteMessage(websocket.TextMessage, data)
+		})
+		defer sess.close()
+
+		conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(websocketPongWait))
+			return nil
+		})
+
+		done := make(chan struct{})
+		defer close(done)
+		go websocketPingLoop(conn, done)
+
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if messageType != websocket.TextMessage && messageType != websocket.BinaryMessage {
+				continue
+			}
+			sess.handle(data)
+		}
+	})
+}
+
+// websocketPingLoop keeps the connection alive through proxies and
+// detects dead peers, until done is closed.
+func websocketPingLoop(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(websocketPingPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+				return
+			}
+		}
+	}
+}